@@ -0,0 +1,93 @@
+package html
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// cssProperties converts the formatting properties of a stylesheet to CSS
+// declarations, in the order they are written.
+var cssProperties = []struct {
+	name string // lower case stylesheet property
+	css  func(value string) string
+}{
+	{"fontsize", length("font-size", "pt")},
+	{"bold", flag("font-weight", "bold", "normal")},
+	{"italic", flag("font-style", "italic", "normal")},
+	{"underline", flag("text-decoration", "underline", "none")},
+	{"smallcaps", flag("font-variant", "small-caps", "normal")},
+	{"superscript", flag("vertical-align", "super", "baseline")},
+	{"color", color},
+	{"justification", justification},
+	{"firstlineindent", length("text-indent", "in")},
+	{"leftmargin", length("margin-left", "in")},
+	{"rightmargin", length("margin-right", "in")},
+	{"spacebefore", length("margin-top", "pt")},
+	{"spaceafter", length("margin-bottom", "pt")},
+}
+
+// CSS returns the rules of a Paratext stylesheet for the classes the
+// renderer gives to the elements of markers (e.g. ".q1" for \q1).
+func CSS(s *parser.Stylesheet) string {
+	var buf bytes.Buffer
+	for _, style := range s.Styles {
+		var decls []string
+		for _, p := range cssProperties {
+			if value, ok := style.Properties[p.name]; ok {
+				if decl := p.css(value); decl != "" {
+					decls = append(decls, decl)
+				}
+			}
+		}
+		if len(decls) > 0 {
+			buf.WriteString("." + style.Marker + " { " + strings.Join(decls, "; ") + "; }\n")
+		}
+	}
+	return buf.String()
+}
+
+// length returns the declaration of a length in the unit of the stylesheet
+// (points or inches)
+func length(property, unit string) func(string) string {
+	return func(value string) string {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return ""
+		}
+		return property + ": " + value + unit
+	}
+}
+
+// flag returns the declaration of a flag such as \Bold, which is turned off
+// by a "-" value
+func flag(property, on, off string) func(string) string {
+	return func(value string) string {
+		if value == "-" {
+			return property + ": " + off
+		}
+		return property + ": " + on
+	}
+}
+
+// color returns the declaration of a \Color, a decimal BGR value
+func color(value string) string {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("color: #%02x%02x%02x", n&0xff, n>>8&0xff, n>>16&0xff)
+}
+
+// justification returns the text alignment of a \Justification
+func justification(value string) string {
+	switch strings.ToLower(value) {
+	case "left", "center", "right":
+		return "text-align: " + strings.ToLower(value)
+	case "both":
+		return "text-align: justify"
+	}
+	return ""
+}
@@ -1,22 +1,30 @@
 package html
 
 import (
-	"fmt"
+	"bytes"
+	"html"
 	"io"
+	"strconv"
+	"unicode"
 
 	"github.com/socceroos/usfm/parser"
 )
 
 // NewRenderer returns a HTML renderer
 func NewRenderer(o Options, r io.Reader) Renderer {
-	html := &HTML{}
-	html.usfmParser = parser.NewParser(r)
+	html := &HTML{stylesheet: o.Stylesheet, markers: parser.DefaultRegistry()}
+	html.usfmParser = parser.NewParserWithOptions(r, parser.Options{Stylesheet: o.Stylesheet})
+	if o.Stylesheet != nil {
+		html.markers = o.Stylesheet.Registry()
+	}
 	return html
 }
 
 // HTML renderer
 type HTML struct {
 	usfmParser *parser.Parser
+	stylesheet *parser.Stylesheet
+	markers    *parser.Registry // classifies the markers of the content
 }
 
 // Render html
@@ -26,10 +34,389 @@ func (h *HTML) Render(w io.Writer) error {
 		return err
 	}
 
-	_, err = w.Write([]byte(fmt.Sprintf("%#v\n", content)))
+	v := &visitor{markers: h.markers}
+	if h.stylesheet != nil {
+		v.buf.WriteString("<style>\n" + CSS(h.stylesheet) + "</style>\n")
+	}
+	parser.Walk(content, v)
+	v.buf.WriteString("\n")
+
+	_, err = w.Write(v.buf.Bytes())
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// visitor writes the HTML of each node of the walked content
+type visitor struct {
+	buf     bytes.Buffer
+	markers *parser.Registry  // classifies the markers of the content
+	space   bool              // a space is needed before the next text
+	quote   *parser.Milestone // quotation of the open quote span, if any
+}
+
+// text writes escaped text, separated from the previous text by a space
+func (v *visitor) text(s string) {
+	if s == "" {
+		return
+	}
+	if v.space && !attaches(s) {
+		v.buf.WriteString(" ")
+	}
+	v.buf.WriteString(html.EscapeString(s))
+	v.space = true
+}
+
+// attaches reports whether text starts with punctuation written without a
+// space before it. Opening brackets and quotes (“, « or the like) start a new
+// word.
+func attaches(s string) bool {
+	r := []rune(s)[0]
+	return unicode.IsPunct(r) && !unicode.In(r, unicode.Ps, unicode.Pi)
+}
+
+// tag writes a HTML tag
+func (v *visitor) tag(s string) {
+	v.closeQuote()
+	v.buf.WriteString(s)
+	v.space = false
+}
+
+// inline writes the start tag of an inline element, separated from the
+// previous text by a space
+func (v *visitor) inline(s string) {
+	v.closeQuote()
+	if v.space {
+		v.buf.WriteString(" ")
+	}
+	v.tag(s)
+}
+
+// openQuote opens a span for text within a quotation milestone
+// (\qt-s ... \qt-e), so that quotes can be styled by speaker
+func (v *visitor) openQuote(c *parser.Cursor, s string) {
+	var quote *parser.Milestone
+	for _, m := range c.Milestones() {
+		if m.Name == "qt" {
+			quote = m
+		}
+	}
+	if quote == v.quote || s == "" {
+		return
+	}
+	v.closeQuote()
+	if quote == nil {
+		return
+	}
+
+	if v.space && !attaches(s) {
+		v.buf.WriteString(" ")
+	}
+	attrs := ` class="qt"`
+	if who := quote.Who(); who != "" {
+		attrs += ` data-who="` + html.EscapeString(who) + `"`
+	}
+	v.buf.WriteString("<span" + attrs + ">")
+	v.space = false
+	v.quote = quote
+}
+
+// closeQuote closes the open quote span, if any
+func (v *visitor) closeQuote() {
+	if v.quote != nil {
+		v.buf.WriteString("</span>")
+		v.quote = nil
+	}
+}
+
+// Enter writes the start of a node
+func (v *visitor) Enter(c *parser.Cursor) bool {
+	node := c.Node
+	switch node.Kind() {
+	case parser.BookNode:
+		v.tag(`<div class="book" data-book="` + html.EscapeString(node.Value) + `">`)
+		return true
+	case parser.TableNode:
+		v.tag("<table>")
+		return true
+	case parser.IntroductionNode:
+		v.tag(`<div class="introduction">`)
+		return true
+	case parser.ListNode:
+		v.tag(`<ul class="list">`)
+		return true
+	case parser.SidebarNode:
+		attrs := ` class="sidebar"`
+		if cat := node.Category(); cat != "" {
+			attrs += ` data-category="` + html.EscapeString(cat) + `"`
+		}
+		v.tag("<aside" + attrs + ">")
+		return true
+	case parser.PeripheralNode:
+		attrs := ` class="periph"`
+		if periph, _ := node.Peripheral(); periph.ID != "" {
+			attrs += ` data-id="` + html.EscapeString(periph.ID) + `"`
+		}
+		v.tag("<section" + attrs + ">")
+		return true
+	case parser.UnknownMarkerNode:
+		// Only the content of unknown markers is written
+		return true
+	case parser.TextNode, parser.HeadingNode, parser.DescriptionNode, parser.SpeakerNode:
+		v.openQuote(c, node.Value)
+		v.text(node.Value)
+		return false
+	case parser.MarkerNode:
+	default:
+		return false
+	}
+
+	name := node.Marker()
+	if name == "cat" {
+		// Categories are written as data-category attributes
+		return false
+	} else if isBreak(c) {
+		v.tag(`<br class="b">`)
+		return false
+	} else if fig, ok := node.Figure(); ok {
+		attrs := ` class="fig"`
+		if fig.Size != "" {
+			attrs += ` data-size="` + html.EscapeString(fig.Size) + `"`
+		}
+		// Figures are written between the paragraphs they are placed in
+		v.closeParagraph(c)
+		v.tag("<figure" + attrs + `><img src="` + html.EscapeString(fig.Source) + `" alt="` + html.EscapeString(fig.Description) + `"><figcaption>`)
+		return true
+	} else if cell, ok := node.TableCell(); ok {
+		t := "td"
+		if cell.Header {
+			t = "th"
+		}
+		attrs := ` class="` + name + `"`
+		if cell.Align != "left" {
+			attrs += ` style="text-align: ` + cell.Align + `"`
+		}
+		if cell.Span > 1 {
+			attrs += ` colspan="` + strconv.Itoa(cell.Span) + `"`
+		}
+		v.tag("<" + t + attrs + ">")
+		return true
+	}
+
+	if _, ok := node.ListItem(); ok {
+		v.tag(`<li class="` + name + `">`)
+		return true
+	} else if tag, ok := paragraphTag(node); ok {
+		v.tag(tag)
+		return true
+	}
+
+	switch v.markers.Kind(node.Value) {
+	case parser.ChapterKind:
+		v.tag(`<h2 class="c">` + html.EscapeString(node.ChapterNumber()) + `</h2>`)
+		return false
+	case parser.VerseKind:
+		if node.IsContinuation() {
+			// The verse number was written in an earlier paragraph or line
+			v.inline(`<span class="v" data-verse="` + html.EscapeString(node.VerseNumber()) + `">`)
+			return true
+		}
+		v.inline(`<span class="v" data-verse="` + html.EscapeString(node.VerseNumber()) + `"><sup class="vn">` + html.EscapeString(node.VerseNumber()) + `</sup>`)
+		v.space = true
+		return true
+	case parser.ParagraphKind:
+		if _, ok := node.Heading(); ok {
+			v.tag(`<h3 class="` + name + `">`)
+		} else if name == "periph" {
+			v.tag(`<h2 class="periph">`)
+		} else if isReference(c) {
+			// References attached to a heading
+			v.inline(`<span class="` + name + `">`)
+		} else if tag, ok := paragraphTag(node); ok {
+			v.tag(tag)
+		} else if name == "tr" {
+			v.tag("<tr>")
+		} else {
+			v.tag(`<p class="` + name + `">`)
+		}
+		return true
+	case parser.CharacterKind:
+		v.inline(`<span class="` + name + `">`)
+		return true
+	}
+	return false
+}
+
+// Leave writes the end of a node
+func (v *visitor) Leave(c *parser.Cursor) {
+	node := c.Node
+	switch node.Kind() {
+	case parser.BookNode:
+		v.tag("</div>")
+		return
+	case parser.TableNode:
+		v.tag("</table>")
+		return
+	case parser.IntroductionNode:
+		v.tag("</div>")
+		return
+	case parser.ListNode:
+		v.tag("</ul>")
+		return
+	case parser.SidebarNode:
+		v.tag("</aside>")
+		return
+	case parser.PeripheralNode:
+		v.tag("</section>")
+		return
+	case parser.MarkerNode:
+	default:
+		return
+	}
+
+	if node.IsMarker("cat") || isBreak(c) {
+		return
+	} else if fig, ok := node.Figure(); ok {
+		if fig.Reference != "" {
+			v.inline(`<span class="ref">`)
+			v.text(fig.Reference)
+			v.buf.WriteString("</span>")
+		}
+		v.tag("</figcaption></figure>")
+		v.openParagraph(c)
+		return
+	} else if cell, ok := node.TableCell(); ok {
+		if cell.Header {
+			v.tag("</th>")
+		} else {
+			v.tag("</td>")
+		}
+		return
+	}
+
+	if _, ok := node.ListItem(); ok {
+		v.tag("</li>")
+		return
+	} else if _, ok := node.Paragraph(); ok {
+		v.tag("</p>")
+		return
+	}
+
+	switch v.markers.Kind(node.Value) {
+	case parser.VerseKind:
+		v.closeQuote()
+		v.buf.WriteString("</span>")
+	case parser.ParagraphKind:
+		if _, ok := node.Heading(); ok {
+			v.tag("</h3>")
+		} else if node.Marker() == "periph" {
+			v.tag("</h2>")
+		} else if isReference(c) {
+			v.tag("</span>")
+			v.space = true
+		} else if node.Marker() == "tr" {
+			v.tag("</tr>")
+		} else {
+			v.tag("</p>")
+		}
+	case parser.CharacterKind:
+		v.closeQuote()
+		v.buf.WriteString("</span>")
+	}
+}
+
+// paragraphTag returns the start tag of a paragraph or poetry line. It
+// returns false for other nodes.
+func paragraphTag(node *parser.Content) (string, bool) {
+	name := node.Marker()
+	if para, ok := node.Paragraph(); ok {
+		attrs := ` class="` + para.Style
+		if para.Indent > 0 && name != para.Style {
+			attrs += " " + name
+		}
+		if para.Embedded {
+			attrs += " embedded"
+		}
+		attrs += `"`
+		if para.Align != "left" {
+			attrs += ` style="text-align: ` + para.Align + `"`
+		}
+		return "<p" + attrs + ">", true
+	} else if line, ok := node.Line(); ok {
+		attrs := ` class="` + name + `"`
+		if line.Align != "left" {
+			attrs += ` style="text-align: ` + line.Align + `"`
+		}
+		return "<p" + attrs + ">", true
+	}
+	return "", false
+}
+
+// paragraphAround returns the paragraph or line containing the node of the
+// cursor with the verses and character spans open between them, or nil.
+func (v *visitor) paragraphAround(c *parser.Cursor) (*parser.Content, []*parser.Content) {
+	for i := len(c.Ancestors) - 1; i >= 0; i-- {
+		if _, ok := paragraphTag(c.Ancestors[i]); !ok {
+			continue
+		}
+		var spans []*parser.Content
+		for _, node := range c.Ancestors[i+1:] {
+			switch v.markers.Kind(node.Value) {
+			case parser.VerseKind, parser.CharacterKind:
+				spans = append(spans, node)
+			}
+		}
+		return c.Ancestors[i], spans
+	}
+	return nil, nil
+}
+
+// closeParagraph closes the paragraph containing the node of the cursor and
+// the spans open within it
+func (v *visitor) closeParagraph(c *parser.Cursor) {
+	para, spans := v.paragraphAround(c)
+	if para == nil {
+		return
+	}
+	v.closeQuote()
+	for range spans {
+		v.buf.WriteString("</span>")
+	}
+	v.tag("</p>")
+}
+
+// openParagraph reopens the paragraph and spans closed by closeParagraph. The
+// verse numbers are not written again.
+func (v *visitor) openParagraph(c *parser.Cursor) {
+	para, spans := v.paragraphAround(c)
+	if para == nil {
+		v.space = true
+		return
+	}
+	tag, _ := paragraphTag(para)
+	for _, node := range spans {
+		if v.markers.Kind(node.Value) == parser.VerseKind {
+			tag += `<span class="v" data-verse="` + html.EscapeString(node.VerseNumber()) + `">`
+		} else {
+			tag += `<span class="` + node.Marker() + `">`
+		}
+	}
+	v.tag(tag)
+}
+
+// isBreak reports whether the node is a stanza break (\b) within a paragraph
+// or verse
+func isBreak(c *parser.Cursor) bool {
+	return c.Node.IsMarker("b") && c.Parent() != nil && c.Parent().Kind() == parser.MarkerNode
+}
+
+// isReference reports whether the node is a reference attached to a heading
+func isReference(c *parser.Cursor) bool {
+	if c.Parent() == nil {
+		return false
+	}
+	_, ok := c.Parent().Heading()
+	return ok
+}
@@ -1 +1,126 @@
 package html_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/html"
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure the renderer writes HTML for the parsed content.
+func TestRender(t *testing.T) {
+	var tests = []struct {
+		s    string
+		html string
+	}{
+		{
+			s:    `\id MAT \c 1 \p \v 1 T1, \wj T2\wj* T3`,
+			html: `<div class="book" data-book="MAT"><h2 class="c">1</h2><p class="p"><span class="v" data-verse="1"><sup class="vn">1</sup> T1, <span class="wj">T2</span> T3</span></p></div>`,
+		},
+		{
+			s:    `\id MAT \c 22 \p \v 37 \wj Love the \+nd Lord\+nd* your God\wj*`,
+			html: `<div class="book" data-book="MAT"><h2 class="c">22</h2><p class="p"><span class="v" data-verse="37"><sup class="vn">37</sup> <span class="wj">Love the <span class="nd">Lord</span> your God</span></span></p></div>`,
+		},
+		{
+			s:    `\id PSA \c 3 \q1 \v 1 T1 \q2 T2 \qr Selah \qc T3`,
+			html: `<div class="book" data-book="PSA"><h2 class="c">3</h2><p class="q1"><span class="v" data-verse="1"><sup class="vn">1</sup> T1</span></p><p class="q2"><span class="v" data-verse="1">T2</span></p><p class="qr" style="text-align: right"><span class="v" data-verse="1">Selah</span></p><p class="qc" style="text-align: center"><span class="v" data-verse="1">T3</span></p></div>`,
+		},
+		{
+			s:    `\id MAT \c 5 \s1 The Beatitudes \r (Luk 6:20-23) \p \v 1 T1`,
+			html: `<div class="book" data-book="MAT"><h2 class="c">5</h2><h3 class="s1">The Beatitudes <span class="r">(Luk 6:20-23)</span></h3><p class="p"><span class="v" data-verse="1"><sup class="vn">1</sup> T1</span></p></div>`,
+		},
+		{
+			s:    `\id 2JN \c 1 ¶ \v 1 T1 \pi2 T2 \pmr T3 \cls T4 \pb`,
+			html: `<div class="book" data-book="2JN"><h2 class="c">1</h2><p class="p"><span class="v" data-verse="1"><sup class="vn">1</sup> T1</span></p><p class="pi pi2"><span class="v" data-verse="1">T2</span></p><p class="pmr embedded" style="text-align: right"><span class="v" data-verse="1">T3</span></p><p class="cls"><span class="v" data-verse="1">T4</span></p><p class="pb"></p></div>`,
+		},
+		{
+			s:    `\id NUM \c 7 \lh Offerings: \li1 \v 12 \lik Day 1\lik* \liv1 Nahshon\liv1* \li2 T2 \lf Total \p T3`,
+			html: `<div class="book" data-book="NUM"><h2 class="c">7</h2><ul class="list"><li class="lh">Offerings:</li><li class="li1"><span class="v" data-verse="12"><sup class="vn">12</sup> <span class="lik">Day 1</span> <span class="liv1">Nahshon</span></span></li><li class="li2"><span class="v" data-verse="12">T2</span></li><li class="lf"><span class="v" data-verse="12">Total</span></li></ul><p class="p"><span class="v" data-verse="12">T3</span></p></div>`,
+		},
+		{
+			s:    `\id MAT \c 5 \p \v 1 He said, \qt-s |who="Jesus"\* “Blessed \v 2 are \wj the\wj* poor.” \qt-e\* Amen`,
+			html: `<div class="book" data-book="MAT"><h2 class="c">5</h2><p class="p"><span class="v" data-verse="1"><sup class="vn">1</sup> He said, <span class="qt" data-who="Jesus">“Blessed</span></span> <span class="v" data-verse="2"><sup class="vn">2</sup> <span class="qt" data-who="Jesus">are</span> <span class="wj"><span class="qt" data-who="Jesus">the</span></span> <span class="qt" data-who="Jesus">poor.”</span> Amen</span></p></div>`,
+		},
+		{
+			s:    `\id PSA \c 1 \q1 \v 1 T1 \b \q1 T2 \b \p \b \v 2 T3`,
+			html: `<div class="book" data-book="PSA"><h2 class="c">1</h2><p class="q1"><span class="v" data-verse="1"><sup class="vn">1</sup> T1<br class="b"></span></p><p class="q1"><span class="v" data-verse="1">T2<br class="b"></span></p><p class="p"><br class="b"><span class="v" data-verse="2"><sup class="vn">2</sup> T3</span></p></div>`,
+		},
+		{
+			s:    `\id MAT \c 6 \p \v 9 T1, (T2) [T3] T4: «T5» ‘T6’.`,
+			html: `<div class="book" data-book="MAT"><h2 class="c">6</h2><p class="p"><span class="v" data-verse="9"><sup class="vn">9</sup> T1, (T2) [T3] T4: «T5» ‘T6’.</span></p></div>`,
+		},
+		{
+			s:    `\id MRK \c 1 \p \v 18 T1 \fig Nets|avnt016.jpg|span|||Simon and Andrew|1.18\fig* \v 19 T2`,
+			html: `<div class="book" data-book="MRK"><h2 class="c">1</h2><p class="p"><span class="v" data-verse="18"><sup class="vn">18</sup> T1</span></p><figure class="fig" data-size="span"><img src="avnt016.jpg" alt="Nets"><figcaption>Simon and Andrew <span class="ref">1.18</span></figcaption></figure><p class="p"><span class="v" data-verse="18"></span><span class="v" data-verse="19"><sup class="vn">19</sup> T2</span></p></div>`,
+		},
+		{
+			s:    `\id GEN \c 1 \p \v 1 T1 \esb \cat History\cat* \ms T2 \p T3 \esbe`,
+			html: `<div class="book" data-book="GEN"><h2 class="c">1</h2><p class="p"><span class="v" data-verse="1"><sup class="vn">1</sup> T1</span></p><aside class="sidebar" data-category="History"><h3 class="ms">T2</h3><p class="p">T3</p></aside></div>`,
+		},
+		{
+			s:    `\id FRT \periph Title Page|id="title" \mt1 T1 \periph Foreword \p T2`,
+			html: `<div class="book" data-book="FRT"><section class="periph" data-id="title"><h2 class="periph">Title Page</h2><p class="mt1">T1</p></section><section class="periph"><h2 class="periph">Foreword</h2><p class="p">T2</p></section></div>`,
+		},
+		{
+			s:    `\id MAT \c 1 \p \v 1 T1 \zx T2\zx* \zy T3`,
+			html: `<div class="book" data-book="MAT"><h2 class="c">1</h2><p class="p"><span class="v" data-verse="1"><sup class="vn">1</sup> T1 T2 T3</span></p></div>`,
+		},
+		{
+			s:    `\tr \th1 A \thr2 B \tr \tc1-2 C&D`,
+			html: `<div class="book" data-book=""><table><tr><th class="th1">A</th><th class="thr2" style="text-align: right">B</th></tr><tr><td class="tc1-2" colspan="2">C&amp;D</td></tr></table></div>`,
+		},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		if err := html.NewRenderer(html.Options{}, strings.NewReader(tt.s)).Render(&buf); err != nil {
+			t.Errorf("%d. %q: unexpected error: %v", i, tt.s, err)
+		} else if got := strings.TrimSpace(buf.String()); got != tt.html {
+			t.Errorf("%d. %q: html mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, tt.html, got)
+		}
+	}
+}
+
+// Ensure a stylesheet adds custom markers and writes their styles as CSS.
+func TestRender_Stylesheet(t *testing.T) {
+	sty, err := parser.ParseStylesheet(strings.NewReader(`\Marker zq1
+\StyleType Paragraph
+\TextType VerseText
+\LeftMargin .5
+\FirstLineIndent -.25
+\Justification Both
+
+\Marker zwj
+\Endmarker zwj*
+\StyleType Character
+\Color 255
+\Bold
+\Italic -
+\FontSize 12
+
+\Marker zs
+\StyleType Paragraph
+\TextType Section`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	s := `\id MAT
+\c 1
+\zs Title
+\zq1 \v 1 Poetry \zwj red\zwj*`
+	if err := html.NewRenderer(html.Options{Stylesheet: sty}, strings.NewReader(s)).Render(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := `<style>
+.zq1 { text-align: justify; text-indent: -.25in; margin-left: .5in; }
+.zwj { font-size: 12pt; font-weight: bold; font-style: normal; color: #ff0000; }
+</style>
+<div class="book" data-book="MAT"><h2 class="c">1</h2><h3 class="zs">Title</h3><p class="zq1"><span class="v" data-verse="1"><sup class="vn">1</sup> Poetry <span class="zwj">red</span></span></p></div>`
+	if got := strings.TrimSpace(buf.String()); got != exp {
+		t.Errorf("html mismatch:\n\nexp=%s\n\ngot=%s\n\n", exp, got)
+	}
+}
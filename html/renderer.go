@@ -1,6 +1,10 @@
 package html
 
-import "io"
+import (
+	"io"
+
+	"github.com/socceroos/usfm/parser"
+)
 
 // Renderer render the parsed content
 type Renderer interface {
@@ -10,4 +14,8 @@ type Renderer interface {
 // Options for rendering
 type Options struct {
 	Title string
+
+	// Stylesheet adds the markers of a Paratext stylesheet to the parser and
+	// writes its styles as CSS before the content
+	Stylesheet *parser.Stylesheet
 }
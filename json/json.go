@@ -13,14 +13,15 @@ import (
 
 // NewRenderer returns a JSON renderer
 func NewRenderer(o Options, r io.Reader) Renderer {
-	json := &JSON{}
-	json.usfmParser = parser.NewParser(r)
+	json := &JSON{options: o}
+	json.usfmParser = parser.NewParserWithOptions(r, parser.Options{Stylesheet: o.Stylesheet})
 	return json
 }
 
 // JSON renderer
 type JSON struct {
 	usfmParser *parser.Parser
+	options    Options
 }
 
 // Render JSON
@@ -32,6 +33,9 @@ func (h *JSON) Render(w io.Writer, startKey int, startByte int64) (endKey int, e
 
 	//converted, endKey := convertV2(content, startKey)
 	converted, endKey := convertToIndex(content, startKey, startByte)
+	if index, ok := converted.(IndexFormat); ok && len(h.options.Categories) > 0 {
+		converted = filterCategories(index, h.options.Categories)
+	}
 
 	jsonEncoder := json.NewEncoder(w)
 	jsonEncoder.SetIndent(" ", "  ")
@@ -390,19 +394,395 @@ func convertV2(in *parser.Content, key int) (interface{}, int) {
 }
 
 type IndexItem struct {
-	ID     int    `json:"id"`
-	RootID int    `json:"rootID"`
-	OSIS   string `json:"osis"`
-	Start  int64  `json:"start"`
-	End    int64  `json:"end"`
-	Type   string `json:"type"`
+	ID        int      `json:"id"`
+	RootID    int      `json:"rootID"`
+	OSIS      string   `json:"osis"`
+	Name      string   `json:"name,omitempty"`      // name of a book
+	Covers    []string `json:"covers,omitempty"`    // OSIS IDs of each verse of a verse range
+	Alt       string   `json:"alt,omitempty"`       // alternate number (\va or \ca)
+	Published string   `json:"published,omitempty"` // published number (\vp or \cp)
+	Start     int64    `json:"start"`
+	End       int64    `json:"end"`
+	Type      string   `json:"type"`
 }
 
 var Index map[string]IndexItem
 
 type IndexFormat struct {
 	Translation Translation       `json:"translation"`
+	Book        BookInfo          `json:"book"`
 	Index       map[int]IndexItem `json:"index"`
+	Footnotes   []Footnote        `json:"footnotes,omitempty"`
+	CrossRefs   []CrossReference  `json:"crossReferences,omitempty"`
+	Tables      []Table           `json:"tables,omitempty"`
+	Headings    []Heading         `json:"headings,omitempty"`
+	Paragraphs  []Paragraph       `json:"paragraphs,omitempty"`
+	Lines       []Line            `json:"lines,omitempty"`
+	Intro       *Introduction     `json:"introduction,omitempty"`
+	Sidebars    []Sidebar         `json:"sidebars,omitempty"`
+	Sections    []Section         `json:"sections,omitempty"`
+
+	// Illustrations lists the figures of each chapter by chapter OSIS ID
+	Illustrations map[string][]Illustration `json:"illustrations,omitempty"`
+}
+
+// Footnote is a translator note attached to a verse
+type Footnote struct {
+	OSIS      string         `json:"osis"`
+	Caller    string         `json:"caller"`
+	Extended  bool           `json:"extended,omitempty"` // study bible note (\ef)
+	Category  string         `json:"category,omitempty"`
+	Reference string         `json:"reference,omitempty"`
+	Text      string         `json:"text"`
+	Parts     []FootnotePart `json:"parts"`
+	Start     int64          `json:"start"`
+}
+
+// FootnotePart is a \fr, \ft, \fq etc. part of a footnote
+type FootnotePart struct {
+	Marker string `json:"marker"`
+	Text   string `json:"text"`
+}
+
+// convertFootnote flattens a parsed footnote into a Footnote
+func convertFootnote(note *parser.Content, osis string, byteStart int64) Footnote {
+	f := Footnote{OSIS: osis, Extended: note.IsMarker("ef"), Category: note.Category(), Start: int64(note.Position) + byteStart}
+	var text []string
+	for _, c := range note.Children {
+		if c.Kind() == parser.CallerNode {
+			f.Caller = c.Value
+		} else if c.IsMarker("cat") {
+			continue
+		} else if c.Kind() == parser.MarkerNode {
+			part := FootnotePart{Marker: c.Marker(), Text: joinText(c)}
+			if part.Marker == "fr" {
+				f.Reference = part.Text
+			} else if part.Text != "" {
+				text = append(text, part.Text)
+			}
+			f.Parts = append(f.Parts, part)
+		} else if c.Kind() == parser.TextNode {
+			text = append(text, c.Value)
+		}
+	}
+	f.Text = strings.Join(text, " ")
+	return f
+}
+
+// CrossReference is a cross-reference note attached to a verse
+type CrossReference struct {
+	OSIS     string   `json:"osis"`
+	Caller   string   `json:"caller"`
+	Extended bool     `json:"extended,omitempty"` // study bible cross reference (\ex)
+	Category string   `json:"category,omitempty"`
+	Origin   string   `json:"origin,omitempty"`
+	Text     string   `json:"text"`
+	Targets  []string `json:"targets"`
+	Start    int64    `json:"start"`
+}
+
+// addNotes adds the footnotes and cross references found in node and its
+// descendants
+func (out *IndexFormat) addNotes(node *parser.Content, osis string, byteStart int64) {
+	parser.Inspect(node, func(c *parser.Cursor) bool {
+		if c.Node.IsMarker("f") || c.Node.IsMarker("fe") || c.Node.IsMarker("ef") {
+			out.Footnotes = append(out.Footnotes, convertFootnote(c.Node, osis, byteStart))
+			return false
+		} else if c.Node.IsMarker("x") || c.Node.IsMarker("ex") {
+			out.CrossRefs = append(out.CrossRefs, convertCrossReference(c.Node, osis, byteStart))
+			return false
+		}
+		return true
+	})
+}
+
+// convertCrossReference flattens a parsed cross-reference into a CrossReference
+func convertCrossReference(note *parser.Content, osis string, byteStart int64) CrossReference {
+	x := CrossReference{OSIS: osis, Extended: note.IsMarker("ex"), Category: note.Category(), Start: int64(note.Position) + byteStart}
+	var text []string
+	for _, c := range note.Children {
+		if c.Kind() == parser.CallerNode {
+			x.Caller = c.Value
+		} else if c.IsMarker("cat") {
+			continue
+		} else if c.IsMarker("xo") {
+			x.Origin = joinText(c)
+		} else if c.Kind() == parser.MarkerNode {
+			if t := joinText(c); t != "" {
+				text = append(text, t)
+			}
+			for _, ref := range c.Children {
+				if ref.Kind() == parser.ReferenceNode {
+					x.Targets = append(x.Targets, ref.Value)
+				}
+			}
+		}
+	}
+	x.Text = strings.Join(text, " ")
+	return x
+}
+
+// Heading is a section heading with the references attached to it
+type Heading struct {
+	OSIS       string   `json:"osis"` // first verse of the section
+	Marker     string   `json:"marker"`
+	Level      int      `json:"level"`
+	Text       string   `json:"text"`
+	References []string `json:"references,omitempty"`
+	Start      int64    `json:"start"`
+	End        int64    `json:"end"`
+}
+
+// Paragraph is a prose paragraph and its style
+type Paragraph struct {
+	OSIS      string `json:"osis,omitempty"` // first verse in the paragraph
+	Marker    string `json:"marker"`
+	Style     string `json:"style"`
+	Indent    int    `json:"indent,omitempty"`
+	Align     string `json:"align"`
+	Embedded  bool   `json:"embedded,omitempty"`
+	Hanging   bool   `json:"hanging,omitempty"`
+	Continued bool   `json:"continued,omitempty"`
+	Start     int64  `json:"start"`
+	End       int64  `json:"end"`
+}
+
+// Line is a poetry line and the classes of its verses
+type Line struct {
+	OSIS   string `json:"osis,omitempty"` // first verse on the line
+	Marker string `json:"marker"`
+	Class  string `json:"class"`
+	Indent int    `json:"indent,omitempty"`
+	Align  string `json:"align"`
+	Start  int64  `json:"start"`
+	End    int64  `json:"end"`
+}
+
+// Sidebar is a study bible sidebar (\esb ... \esbe)
+type Sidebar struct {
+	OSIS       string  `json:"osis"` // verse (or chapter) the sidebar follows
+	Category   string  `json:"category,omitempty"`
+	Paragraphs []Block `json:"paragraphs"`
+	Start      int64   `json:"start"`
+	End        int64   `json:"end"`
+}
+
+// Block is a heading or paragraph of a sidebar or peripheral section
+type Block struct {
+	Marker string `json:"marker"`
+	Text   string `json:"text"`
+}
+
+// convertSidebar converts a sidebar following the given verse or chapter
+func convertSidebar(sidebar *parser.Content, osis string, byteStart int64) Sidebar {
+	out := Sidebar{OSIS: osis, Category: sidebar.Category(), Start: int64(sidebar.Position) + byteStart, End: int64(sidebar.Span.End.Offset) + byteStart - 1}
+	for _, c := range blocks(sidebar) {
+		if c.Kind() == parser.MarkerNode && !c.IsMarker("cat") {
+			out.Paragraphs = append(out.Paragraphs, Block{Marker: c.Marker(), Text: joinText(c)})
+		}
+	}
+	return out
+}
+
+// Section is a non-canonical division of a peripheral book (\periph)
+type Section struct {
+	OSIS       string  `json:"osis"` // book code and division id (e.g. "FRT.title")
+	ID         string  `json:"id,omitempty"`
+	Title      string  `json:"title"`
+	Paragraphs []Block `json:"paragraphs"`
+	Start      int64   `json:"start"`
+	End        int64   `json:"end"`
+}
+
+// convertSection converts a peripheral division
+func convertSection(periph *parser.Content, osis string, byteStart int64) Section {
+	info, _ := periph.Peripheral()
+	out := Section{OSIS: osis, ID: info.ID, Title: info.Title, Start: int64(periph.Position) + byteStart, End: int64(periph.Span.End.Offset) + byteStart - 1}
+	for _, c := range blocks(periph) {
+		if c.Kind() == parser.IntroductionNode {
+			for _, ic := range c.Children {
+				out.Paragraphs = append(out.Paragraphs, Block{Marker: ic.Marker(), Text: joinText(ic)})
+			}
+		} else if c.Kind() == parser.MarkerNode && !c.IsMarker("periph") {
+			out.Paragraphs = append(out.Paragraphs, Block{Marker: c.Marker(), Text: joinText(c)})
+		}
+	}
+	return out
+}
+
+// filterCategories drops the extended notes and sidebars with a category
+// not listed. Notes and sidebars without a category are kept.
+func filterCategories(in IndexFormat, categories []string) IndexFormat {
+	keep := func(category string) bool {
+		if category == "" {
+			return true
+		}
+		for _, c := range categories {
+			if strings.EqualFold(c, category) {
+				return true
+			}
+		}
+		return false
+	}
+
+	footnotes, crossRefs, sidebars := in.Footnotes[:0], in.CrossRefs[:0], in.Sidebars[:0]
+	for _, f := range in.Footnotes {
+		if keep(f.Category) {
+			footnotes = append(footnotes, f)
+		}
+	}
+	for _, x := range in.CrossRefs {
+		if keep(x.Category) {
+			crossRefs = append(crossRefs, x)
+		}
+	}
+	for _, s := range in.Sidebars {
+		if keep(s.Category) {
+			sidebars = append(sidebars, s)
+		}
+	}
+	in.Footnotes, in.CrossRefs, in.Sidebars = footnotes, crossRefs, sidebars
+	return in
+}
+
+// Illustration is a \fig figure placed in a chapter
+type Illustration struct {
+	OSIS        string `json:"osis"` // verse (or chapter) the figure is placed in
+	Caption     string `json:"caption,omitempty"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"src"`
+	Size        string `json:"size,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Copyright   string `json:"copyright,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	Start       int64  `json:"start"`
+}
+
+// convertIllustrations collects the figures of a book by chapter
+func convertIllustrations(in *parser.Content, byteStart int64) map[string][]Illustration {
+	var out map[string][]Illustration
+	parser.Inspect(in, func(c *parser.Cursor) bool {
+		fig, ok := c.Node.Figure()
+		if !ok {
+			return !c.Node.IsNote()
+		}
+		chapter := c.Book
+		if c.Chapter != "" {
+			chapter += "." + c.Chapter
+		}
+		if out == nil {
+			out = map[string][]Illustration{}
+		}
+		out[chapter] = append(out[chapter], Illustration{OSIS: c.Reference(), Caption: fig.Caption, Description: fig.Description, Source: fig.Source, Size: fig.Size, Location: fig.Location, Copyright: fig.Copyright, Reference: fig.Reference, Start: int64(c.Node.Position) + byteStart})
+		return false
+	})
+	return out
+}
+
+// Table is a \tr table with its rows of cells
+type Table struct {
+	OSIS  string     `json:"osis"`
+	Rows  []TableRow `json:"rows"`
+	Start int64      `json:"start"`
+}
+
+// TableRow is a \tr row of a table
+type TableRow struct {
+	Cells []TableCell `json:"cells"`
+}
+
+// TableCell is a \th#, \thr#, \tc# or \tcr# cell of a table row
+type TableCell struct {
+	Text   string `json:"text"`
+	Header bool   `json:"header,omitempty"`
+	Align  string `json:"align"`
+	Column int    `json:"column"`
+	Span   int    `json:"span"`
+}
+
+// BookInfo is the header information of a book
+type BookInfo struct {
+	OSIS         string      `json:"osis"`
+	Name         string      `json:"name"`
+	LongName     string      `json:"longName,omitempty"`
+	ShortName    string      `json:"shortName,omitempty"`
+	Abbreviation string      `json:"abbreviation,omitempty"`
+	Titles       []BookTitle `json:"titles,omitempty"`
+	EndingTitles []BookTitle `json:"endingTitles,omitempty"`
+	USFMVersion  string      `json:"usfmVersion,omitempty"`
+	Status       string      `json:"status,omitempty"`
+	Remarks      []string    `json:"remarks,omitempty"`
+	Peripheral   bool        `json:"peripheral,omitempty"` // non-scripture book such as FRT or GLO
+}
+
+// BookTitle is a main or ending title of a book
+type BookTitle struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// convertBook converts the metadata of a book
+func convertBook(m parser.Metadata) BookInfo {
+	b := BookInfo{OSIS: m.Code, Name: m.Name(), LongName: m.LongName, ShortName: m.ShortName, Abbreviation: m.Abbreviation, USFMVersion: m.USFMVersion, Status: m.Status, Remarks: m.Remarks, Peripheral: parser.IsPeripheral(m.Code)}
+	for _, t := range m.Titles {
+		b.Titles = append(b.Titles, BookTitle{Level: t.Level, Text: t.Text})
+	}
+	for _, t := range m.EndingTitles {
+		b.EndingTitles = append(b.EndingTitles, BookTitle{Level: t.Level, Text: t.Text})
+	}
+	return b
+}
+
+// Introduction is the introduction of a book (\imt, \is, \ip ... \ie)
+type Introduction struct {
+	OSIS       string           `json:"osis"`
+	Paragraphs []IntroParagraph `json:"paragraphs"`
+	Outline    []OutlineEntry   `json:"outline,omitempty"`
+	Start      int64            `json:"start"`
+}
+
+// IntroParagraph is a title, heading or paragraph of an introduction
+type IntroParagraph struct {
+	Marker string `json:"marker"`
+	Text   string `json:"text"`
+}
+
+// OutlineEntry is an \io# entry of an introduction outline
+type OutlineEntry struct {
+	Level     int      `json:"level"`
+	Text      string   `json:"text"`
+	Reference string   `json:"reference,omitempty"`
+	Targets   []string `json:"targets,omitempty"`
+}
+
+// convertIntroduction converts an introduction node
+func convertIntroduction(intro *parser.Content, osis string, byteStart int64) *Introduction {
+	out := &Introduction{OSIS: osis, Start: int64(intro.Position) + byteStart}
+	for _, c := range intro.Children {
+		if c.IsMarker("ie") {
+			continue
+		}
+		out.Paragraphs = append(out.Paragraphs, IntroParagraph{Marker: c.Marker(), Text: joinText(c)})
+	}
+	for _, e := range intro.Outline() {
+		out.Outline = append(out.Outline, OutlineEntry{Level: e.Level, Text: e.Text, Reference: e.Reference, Targets: e.OSIS})
+	}
+	return out
+}
+
+// joinText joins all text below a node (except notes), without spaces before punctuation
+func joinText(in *parser.Content) string {
+	var out string
+	parser.Inspect(in, func(c *parser.Cursor) bool {
+		if c.Node.Kind() == parser.TextNode && c.Node.Value != "" {
+			if out != "" && !unicode.IsPunct([]rune(c.Node.Value)[0]) {
+				out += " "
+			}
+			out += c.Node.Value
+		}
+		return c.Node == in || (c.Node.Kind() == parser.MarkerNode && !c.Node.IsNote()) || c.Node.Kind() == parser.UnknownMarkerNode
+	})
+	return out
 }
 
 func convertToIndex(in *parser.Content, key int, byteStart int64) (interface{}, int) {
@@ -413,11 +793,31 @@ func convertToIndex(in *parser.Content, key int, byteStart int64) (interface{},
 
 	chapter := 0
 	verse := 0
+	// OSIS ID of the last indexed verse, continued by its later segments
+	verseOSIS := ""
 	ch := IndexItem{}
 	book := IndexItem{}
-	bookName := ""
+	metadata := in.Metadata()
+	out.Book = convertBook(metadata)
+	out.Illustrations = convertIllustrations(in, byteStart)
+	// headings waiting for the first verse of their section
+	var pending []int
+	// books without \h start at their \id
+	bookStart := int64(in.Position) + byteStart
 	for _, row := range in.Children {
-		if row.Value == "\\c" {
+		if row.IsMarker("id") {
+			bookStart = int64(row.Position) + byteStart
+			break
+		}
+	}
+	for _, row := range blocks(in) {
+		if row.IsMarker("c") {
+			if book.Type == "" {
+				// Books without \h are indexed at the first chapter
+				key++
+				book = IndexItem{Type: "book", ID: key, RootID: key, OSIS: in.Value, Name: metadata.Name(), Start: bookStart}
+				out.Index[key] = book
+			}
 			key++
 			var err error
 			chapter, err = strconv.Atoi(row.Children[0].Value)
@@ -425,55 +825,133 @@ func convertToIndex(in *parser.Content, key int, byteStart int64) (interface{},
 				log.Printf("Error: %v", err)
 				chapter++
 			}
-			ch = IndexItem{Type: "chapter", ID: key, RootID: key, OSIS: book.OSIS + "." + row.Children[0].Value, Start: int64(row.Position) + byteStart}
+			ch = IndexItem{Type: "chapter", ID: key, RootID: key, OSIS: book.OSIS + "." + row.Children[0].Value, Alt: row.AltNumber(), Published: row.PublishedNumber(), Start: int64(row.Position) + byteStart}
 			out.Index[key] = ch
 			prevItem := out.Index[key-1]
 			prevItem.End = ch.Start - 1
 			out.Index[key-1] = prevItem
 			verse = 0
-		} else if row.Value == "\\h" {
+			verseOSIS = ""
+		} else if row.Kind() == parser.IntroductionNode {
+			intro := convertIntroduction(row, in.Value, byteStart)
+			if out.Intro == nil {
+				out.Intro = intro
+			} else {
+				out.Intro.Paragraphs = append(out.Intro.Paragraphs, intro.Paragraphs...)
+				out.Intro.Outline = append(out.Intro.Outline, intro.Outline...)
+			}
+		} else if row.Kind() == parser.PeripheralNode {
+			if book.Type == "" {
+				// Peripheral books without \h are indexed at the first division
+				key++
+				book = IndexItem{Type: "book", ID: key, RootID: key, OSIS: in.Value, Name: metadata.Name(), Start: bookStart}
+				out.Index[key] = book
+			}
+			// Divisions are indexed as sections instead of chapters and verses
+			id := strconv.Itoa(len(out.Sections) + 1)
+			if periph, _ := row.Peripheral(); periph.ID != "" {
+				id = periph.ID
+			}
+			section := convertSection(row, book.OSIS+"."+id, byteStart)
+			out.Sections = append(out.Sections, section)
 			key++
-			cHead := `<span class="bible-book">`
-			for _, v := range row.Children {
-				if v.Type == "heading" {
-					cHead += v.Value + " "
-					bookName += v.Value + " "
+			item := IndexItem{Type: "section", ID: key, RootID: key, OSIS: section.OSIS, Name: section.Title, Start: section.Start}
+			out.Index[key] = item
+			prevItem := out.Index[key-1]
+			prevItem.End = item.Start - 1
+			out.Index[key-1] = prevItem
+		} else if row.Kind() == parser.SidebarNode {
+			osis := ch.OSIS
+			if verse > 0 {
+				osis += "." + strconv.Itoa(verse)
+			}
+			out.Sidebars = append(out.Sidebars, convertSidebar(row, osis, byteStart))
+		} else if row.Kind() == parser.TableNode {
+			table := Table{OSIS: ch.OSIS, Start: int64(row.Position) + byteStart}
+			for _, tr := range row.Children {
+				r := TableRow{}
+				for _, tc := range tr.Children {
+					cell, ok := tc.TableCell()
+					if !ok {
+						continue
+					}
+					// Verses may start in any cell
+					parser.Inspect(tc, func(c *parser.Cursor) bool {
+						if !c.Node.IsMarker("v") {
+							return true
+						}
+						verses, err := c.Node.VerseRange()
+						if err != nil {
+							log.Printf("Error: %v", err)
+							return false
+						}
+						verse = verses.End
+						if verses.StartSegment != "" && verses.OSIS(ch.OSIS) == verseOSIS {
+							// A later segment continues the verse already indexed
+							return false
+						}
+						key++
+						vC := verseItem(key, c.Node, verses, ch.OSIS, byteStart)
+						verseOSIS = vC.OSIS
+						out.Index[key] = vC
+						for _, i := range pending {
+							out.Headings[i].OSIS = vC.OSIS
+						}
+						pending = nil
+						prevItem := out.Index[key-1]
+						prevItem.End = vC.Start - 1
+						out.Index[key-1] = prevItem
+						return false
+					})
+					r.Cells = append(r.Cells, TableCell{Text: joinText(tc), Header: cell.Header, Align: cell.Align, Column: cell.Column, Span: cell.Span})
 				}
+				table.Rows = append(table.Rows, r)
 			}
-			cHead = strings.TrimSpace(cHead)
-			bookName = strings.TrimSpace(bookName)
-			cHead += "</span>"
-			book = IndexItem{Type: "book", ID: key, RootID: key, OSIS: in.Value, Start: int64(row.Position) + byteStart}
+			out.Tables = append(out.Tables, table)
+		} else if row.IsMarker("h") && book.Type == "" {
+			key++
+			book = IndexItem{Type: "book", ID: key, RootID: key, OSIS: in.Value, Name: metadata.Name(), Start: int64(row.Position) + byteStart}
 			out.Index[key] = book
-		} else if row.Value == "\\d" {
+		} else if row.IsMarker("d") {
 			var desc string
 			desc += "<span class='description'>"
 			for _, c := range row.Children {
-				if c.Type == "description" {
+				if c.Kind() == parser.DescriptionNode {
 					desc += " "
 					desc += c.Value
 				}
 			}
 			desc += "</span>"
-		} else if row.Value == "\\p" || row.Value == "\\nb" || row.Value == "\\m" {
-			hasQ1Marker := false
-			q1Count := 0
-			hasQ2Marker := false
-			q2Count := 0
+		} else if heading, ok := row.Heading(); ok {
+			h := Heading{Marker: row.Marker(), Level: heading.Level, Text: heading.Text, References: heading.References, Start: int64(row.Position) + byteStart, End: int64(row.Span.End.Offset) + byteStart - 1}
+			pending = append(pending, len(out.Headings))
+			out.Headings = append(out.Headings, h)
+			out.addNotes(row, ch.OSIS, byteStart)
+		} else if row.IsParagraph() {
+			// Poetry lines are paragraphs of their own
+			var qClass string
+			if line, ok := row.Line(); ok {
+				l := convertLine(row, line, ch.OSIS, byteStart)
+				out.Lines = append(out.Lines, l)
+				qClass = " " + l.Class
+			}
+			if para, ok := row.Paragraph(); ok {
+				out.Paragraphs = append(out.Paragraphs, convertParagraph(row, para, ch.OSIS, byteStart))
+			}
 			pText := "<div class='paragraph-start'></div>"
 			for _, v := range row.Children {
-				if v.Type == "text" {
+				if v.Kind() == parser.TextNode {
 					if !unicode.IsPunct([]rune(v.Value)[0]) || []rune(v.Value)[0] == 0x201C {
 						pText += " "
 					}
 
 					pText += v.Value
-				} else if v.Value == "\\sp" {
-				} else if v.Value == "\\d" {
+				} else if v.IsMarker("sp") {
+				} else if v.IsMarker("d") {
 					var desc string
 					desc += "<span class='description'>"
 					for _, c := range v.Children {
-						if c.Type == "description" {
+						if c.Kind() == parser.DescriptionNode {
 							desc += " "
 							desc += c.Value
 						}
@@ -484,142 +962,89 @@ func convertToIndex(in *parser.Content, key int, byteStart int64) (interface{},
 					prevItem := out.Index[key-1]
 					prevItem.End = d.Start - 1
 					out.Index[key-1] = prevItem
-				} else if v.Value == "\\q1" {
-					hasQ1Marker = true
-					q1Count++
-				} else if v.Value == "\\q2" {
-					hasQ2Marker = true
-					q2Count++
-				} else if v.Value == "\\v" {
-					verse++
-					isSubVerse := false
+				} else if v.IsMarker("v") {
+					// A sub-verse paragraph continues the verse already indexed
+					isSubVerse := v.IsContinuation()
+					if !isSubVerse {
+						verse++
+					}
+					verses := parser.VerseRange{Start: verse, End: verse}
 					var verseText string
-					var qClass string
 
-					for _, vC := range v.Children {
-						if vC.Type == "versenumber" {
-							var err error
-							verse, err = strconv.Atoi(vC.Value)
-							if err != nil {
-								log.Printf("Error: %v", err)
-							}
-						} else if vC.Type == "subverse" {
-							isSubVerse = true
+					if n := v.VerseNumber(); n != "" {
+						var err error
+						verses, err = parser.ParseVerseRange(n)
+						if err != nil {
+							log.Printf("Error: %v", err)
+							verses = parser.VerseRange{Start: verse, End: verse}
 						}
+						verse = verses.End
 					}
+					// A later segment (\v 6b after \v 6a) continues the verse already indexed
+					isSegment := !isSubVerse && verses.StartSegment != "" && verses.OSIS(ch.OSIS) == verseOSIS
 
-					if v.Children[1].Value == "\\q1" {
-						qClass = " poetic"
-					}
-					if v.Children[1].Value == "\\q2" {
-						qClass = " poetic"
-					}
-
-					if !isSubVerse {
+					if isSegment {
+						verseText += "<span class='bible-verse r" + strconv.Itoa(key) + " v" + strconv.Itoa(verse) + qClass + "'>"
+						verseText += "<span class='bible-verse-number r" + strconv.Itoa(key) + " v" + strconv.Itoa(verse) + "'>" + verses.String() + "</span>"
+					} else if !isSubVerse {
 						key++
 						verseText += "<span class='bible-verse r" + strconv.Itoa(key) + " v" + strconv.Itoa(verse) + qClass + "'>"
-						verseText += "<span class='bible-verse-number r" + strconv.Itoa(key) + " v" + strconv.Itoa(verse) + "'>" + strconv.Itoa(verse) + "</span>"
+						verseText += "<span class='bible-verse-number r" + strconv.Itoa(key) + " v" + strconv.Itoa(verse) + "'>" + verses.String() + "</span>"
 					} else {
 						verseText += "<span class='bible-verse r" + strconv.Itoa(key) + " v" + strconv.Itoa(verse) + qClass + "'>"
 					}
 
-					// If we have a poetic marker then add the span
-					if hasQ1Marker && q1Count == 1 && q2Count == 0 {
-						verseText += "<span class='poetic-1'>"
-					} else if hasQ1Marker && q1Count > 1 {
-						verseText += "</span><br><span class='poetic-1'>"
-					}
-					if hasQ2Marker && q2Count == 1 && q1Count == 0 {
-						verseText += "<span class='poetic-2'>"
-					} else if hasQ2Marker && (q2Count > 1 || q1Count >= 1) {
-						verseText += "</span><br><span class='poetic-2'>"
-					}
-
-					for i, vC := range v.Children {
-						if vC.Type == "marker" {
-							if vC.Value == "\\c" {
+					for _, vC := range v.Children {
+						if vC.Kind() == parser.MarkerNode {
+							if vC.IsMarker("c") {
 								break
-							} else if vC.Value == "\\qs" {
+							}
+							// Notes may be nested in spans such as \wj
+							out.addNotes(vC, verses.OSIS(ch.OSIS), byteStart)
+							if vC.IsMarker("qs") {
 								log.Print("Found qs marker")
 								verseText += "<span class='qs'>Selah</span>"
-							} else if vC.Value == "\\sp" {
-							} else if vC.Value == "\\q1" {
-								hasQ1Marker = true
-								q1Count++
-
-								if q1Count == 1 && qClass == "" && i > 1 {
-									verseText += "<div class='poetic-start'></div>"
-								}
-								if i > 1 && q1Count > 1 && q2Count == 0 {
-									verseText += "<br>"
-								}
-
-								// If we have a poetic marker then add the span
-								if hasQ1Marker && q1Count == 1 && q2Count == 0 {
-									verseText += "<span class='poetic-1'>"
-								} else if hasQ1Marker && q1Count > 1 {
-									verseText += "</span><br><span class='poetic-1'>"
-								}
-							} else if vC.Value == "\\q2" {
-								hasQ2Marker = true
-								q2Count++
-
-								if q2Count == 1 && qClass == "" && i > 1 && q1Count == 0 {
-									verseText += "<div class='poetic-start'></div>"
-								}
-								if i > 1 && q2Count > 1 && q1Count == 0 {
-									verseText += "<br>"
-								}
-
-								// If we have a poetic marker then add the span
-								if hasQ2Marker && q2Count == 1 && q1Count == 0 {
-									verseText += "<span class='poetic-2'>"
-								} else if hasQ2Marker && (q2Count > 1 || q1Count >= 1) {
-									verseText += "</span><br><span class='poetic-2'>"
-								}
-							} else if vC.Value == "\\wj" {
+							} else if vC.IsMarker("sp") {
+							} else if vC.IsMarker("wj") {
 								verseText += `<span class='jesus-words'>`
 							}
-							// Get all text from markers (except qs marker)
-							if vC.Value != "\\qs" {
-								for _, wl := range vC.Children {
-									if wl.Type == "text" {
-										if !unicode.IsPunct([]rune(wl.Value)[0]) {
-											verseText += " "
-										}
-										verseText += wl.Value
+							// Get all text from markers (except qs marker, notes and figures)
+							if !vC.IsMarker("qs") && !vC.IsNote() && !vC.IsMarker("fig") {
+								// Including the text of nested spans (\+nd ...\+nd*)
+								if t := joinText(vC); t != "" {
+									if !unicode.IsPunct([]rune(t)[0]) {
+										verseText += " "
 									}
+									verseText += t
 								}
 							}
-							if vC.Value == "\\wj" {
+							if vC.IsMarker("wj") {
 								verseText += `</span>`
 							}
-						} else if vC.Type == "text" {
-							if !unicode.IsPunct([]rune(vC.Value)[0]) {
-							}
+						} else if vC.Kind() == parser.TextNode {
 							verseText += " "
 							verseText += vC.Value
 						}
 					}
 					log.Printf("Chapter %v Verse %v", chapter, verse)
-					// Close our poetic lines
-					if hasQ1Marker || hasQ2Marker {
-						verseText += "</span>"
-
-						// Reset our poetry vars at the end of each verse
-						hasQ1Marker = false
-						hasQ2Marker = false
-						q1Count = 0
-						q2Count = 0
-					}
 					// Close the verse
 					verseText += "</span>"
 					pText += strings.TrimSpace(verseText)
-					vC := IndexItem{Type: "verse", ID: key, RootID: key, OSIS: ch.OSIS + "." + strconv.Itoa(verse), Start: int64(v.Position) + byteStart}
-					out.Index[key] = vC
-					prevItem := out.Index[key-1]
-					prevItem.End = vC.Start - 1
-					out.Index[key-1] = prevItem
+					if !isSubVerse && !isSegment {
+						vC := verseItem(key, v, verses, ch.OSIS, byteStart)
+						verseOSIS = vC.OSIS
+						out.Index[key] = vC
+						for _, i := range pending {
+							out.Headings[i].OSIS = vC.OSIS
+						}
+						pending = nil
+						prevItem := out.Index[key-1]
+						prevItem.End = vC.Start - 1
+						out.Index[key-1] = prevItem
+					}
+				} else if v.Kind() == parser.MarkerNode {
+					// Notes before the first verse of the chapter
+					out.addNotes(v, ch.OSIS, byteStart)
 				}
 			}
 			if len(row.Children) > 0 {
@@ -642,3 +1067,55 @@ func convertToIndex(in *parser.Content, key int, byteStart int64) (interface{},
 
 	return out, key
 }
+
+// blocks returns the top level blocks of a book with the items of lists in
+// place of the lists
+func blocks(in *parser.Content) []*parser.Content {
+	var rows []*parser.Content
+	for _, row := range in.Children {
+		if row.Kind() == parser.ListNode {
+			rows = append(rows, row.Children...)
+		} else {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// convertParagraph converts a prose paragraph of a chapter
+func convertParagraph(row *parser.Content, para parser.Paragraph, chapter string, byteStart int64) Paragraph {
+	out := Paragraph{Marker: row.Marker(), Style: para.Style, Indent: para.Indent, Align: para.Align, Embedded: para.Embedded, Hanging: para.Hanging, Continued: para.Continued, Start: int64(row.Position) + byteStart, End: int64(row.Span.End.Offset) + byteStart - 1}
+	for _, v := range row.Children {
+		if verses, err := v.VerseRange(); err == nil {
+			out.OSIS = verses.OSIS(chapter)
+			break
+		}
+	}
+	return out
+}
+
+// convertLine converts a poetry line of a chapter
+func convertLine(row *parser.Content, line parser.Line, chapter string, byteStart int64) Line {
+	out := Line{Marker: row.Marker(), Class: "poetic poetic-" + line.Style, Indent: line.Indent, Align: line.Align, Start: int64(row.Position) + byteStart, End: int64(row.Span.End.Offset) + byteStart - 1}
+	if line.Indent > 0 {
+		out.Class += strconv.Itoa(line.Indent)
+	}
+	for _, v := range row.Children {
+		if verses, err := v.VerseRange(); err == nil {
+			out.OSIS = verses.OSIS(chapter)
+			break
+		}
+	}
+	return out
+}
+
+// verseItem returns the index item of a verse (or verse range) in a chapter
+func verseItem(key int, v *parser.Content, verses parser.VerseRange, chapter string, byteStart int64) IndexItem {
+	item := IndexItem{Type: "verse", ID: key, RootID: key, OSIS: verses.OSIS(chapter), Alt: v.AltNumber(), Published: v.PublishedNumber(), Start: int64(v.Position) + byteStart}
+	if verses.IsRange() {
+		for _, n := range verses.Verses() {
+			item.Covers = append(item.Covers, chapter+"."+strconv.Itoa(n))
+		}
+	}
+	return item
+}
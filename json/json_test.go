@@ -1 +1,348 @@
 package json_test
+
+import (
+	"bytes"
+	encjson "encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/json"
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure the book name falls back from \toc1 to \toc2, \h and \mt1.
+func TestRender_BookName(t *testing.T) {
+	var tests = []struct {
+		header string
+		name   string
+	}{
+		{header: `\h T1`, name: "T1"},
+		{header: `\toc2 T1`, name: "T1"},
+		{header: `\mt1 T1`, name: "T1"},
+		{header: `\toc1 T1 \toc2 T2 \h T3 \mt1 T4`, name: "T1"},
+		{header: `\toc2 T1 \h T2 \mt1 T3`, name: "T1"},
+		{header: `\h T1 \mt1 T2`, name: "T1"},
+		{header: `\mt2 T1 \mt1 T2`, name: "T2"},
+		{header: ``, name: "GEN"},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		s := `\id GEN ` + tt.header + ` \c 1 \p \v 1 T5`
+		if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+			t.Errorf("%d. unexpected error: %v", i, err)
+			continue
+		}
+
+		var out struct {
+			Book  struct{ Name string }
+			Index map[int]json.IndexItem
+		}
+		if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Errorf("%d. unexpected error: %v", i, err)
+			continue
+		}
+		if out.Book.Name != tt.name {
+			t.Errorf("%d. %q: book name mismatch: exp=%q got=%q", i, tt.header, tt.name, out.Book.Name)
+		}
+		if item := out.Index[1]; item.Type != "book" || item.Name != tt.name {
+			t.Errorf("%d. %q: book item mismatch: exp=%q got=%+v", i, tt.header, tt.name, item)
+		} else if item.End < item.Start || !strings.Contains(tt.header, `\h`) && item.Start != 0 {
+			// Books without \h start at their \id
+			t.Errorf("%d. %q: book item range mismatch: start=%d end=%d", i, tt.header, item.Start, item.End)
+		}
+	}
+}
+
+// Ensure poetry lines are output with their classes and the verse they
+// start in.
+func TestRender_Lines(t *testing.T) {
+	s := `\id PSA \c 3 \q1 \v 1 T1 \q2 T2 \v 2 T3 \q1 T4 \qr \v 3 T5 \qm2 T6 \q T7`
+	var buf bytes.Buffer
+	if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Lines []json.Line
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, l := range out.Lines {
+		got = append(got, l.OSIS+" "+l.Marker+" "+l.Class+" "+l.Align)
+	}
+	exp := []string{
+		"PSA.3.1 q1 poetic poetic-q1 left",
+		"PSA.3.1 q2 poetic poetic-q2 left",
+		"PSA.3.2 q1 poetic poetic-q1 left",
+		"PSA.3.3 qr poetic poetic-qr right",
+		"PSA.3.3 qm2 poetic poetic-qm2 left",
+		"PSA.3.3 q poetic poetic-q1 left",
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("lines mismatch:\n\nexp=%q\n\ngot=%q\n\n", exp, got)
+	}
+}
+
+// Ensure verse ranges, segments and alternate numbers are indexed with one
+// item per OSIS ID.
+func TestRender_Verses(t *testing.T) {
+	s := `\id GEN \c 1 \p \v 1-3 T1 \v 4 \va 5\va* \vp 4b\vp* T2 \v 6a T3 \q1 \v 6b T4 \v 7 T5`
+	var buf bytes.Buffer
+	if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Index map[int]json.IndexItem
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []json.IndexItem
+	for i := 1; i <= len(out.Index); i++ {
+		item := out.Index[i]
+		if item.Type == "verse" {
+			got = append(got, json.IndexItem{OSIS: item.OSIS, Covers: item.Covers, Alt: item.Alt, Published: item.Published})
+		}
+	}
+	exp := []json.IndexItem{
+		{OSIS: "GEN.1.1-GEN.1.3", Covers: []string{"GEN.1.1", "GEN.1.2", "GEN.1.3"}},
+		{OSIS: "GEN.1.4", Alt: "5", Published: "4b"},
+		{OSIS: "GEN.1.6"},
+		{OSIS: "GEN.1.7"},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("verses mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+}
+
+// Ensure figures in both the USFM 2 and USFM 3 form are output by chapter.
+func TestRender_Illustrations(t *testing.T) {
+	s := `\id MRK \c 1 \p \v 18 T1 \fig Nets|avnt016.jpg|span|||Simon and Andrew|1.18\fig*
+\c 2 \p T2 \fig Jesus|src="avnt017.jpg" size="col" copy="David C. Cook" ref="2.1"\fig*`
+	var buf bytes.Buffer
+	if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Illustrations map[string][]json.Illustration
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := map[string][]json.Illustration{
+		"MRK.1": {{OSIS: "MRK.1.18", Caption: "Simon and Andrew", Description: "Nets", Source: "avnt016.jpg", Size: "span", Reference: "1.18", Start: int64(strings.Index(s, `\fig Nets`)) + 10}},
+		"MRK.2": {{OSIS: "MRK.2", Caption: "Jesus", Source: "avnt017.jpg", Size: "col", Copyright: "David C. Cook", Reference: "2.1", Start: int64(strings.Index(s, `\fig Jesus`)) + 10}},
+	}
+	if !reflect.DeepEqual(exp, out.Illustrations) {
+		t.Errorf("illustrations mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, out.Illustrations)
+	}
+}
+
+// Ensure footnotes and cross references are output with their parts.
+func TestRender_Notes(t *testing.T) {
+	s := `\id GEN \c 1 \p \v 1 T1\f + \fr 1.1 \ft T2 \fq T3\f* T4\x - \xo 1.1 \xt Joh 1:1\x*`
+	var buf bytes.Buffer
+	if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Footnotes []json.Footnote
+		CrossRefs []json.CrossReference `json:"crossReferences"`
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expFootnotes := []json.Footnote{{
+		OSIS:      "GEN.1.1",
+		Caller:    "+",
+		Reference: "1.1",
+		Text:      "T2 T3",
+		Parts:     []json.FootnotePart{{Marker: "fr", Text: "1.1"}, {Marker: "ft", Text: "T2"}, {Marker: "fq", Text: "T3"}},
+		Start:     int64(strings.Index(s, `\f `)) + 100,
+	}}
+	if !reflect.DeepEqual(expFootnotes, out.Footnotes) {
+		t.Errorf("footnotes mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", expFootnotes, out.Footnotes)
+	}
+	expCrossRefs := []json.CrossReference{{
+		OSIS:    "GEN.1.1",
+		Caller:  "-",
+		Origin:  "1.1",
+		Text:    "Joh 1:1",
+		Targets: []string{"JHN.1.1"},
+		Start:   int64(strings.Index(s, `\x `)) + 100,
+	}}
+	if !reflect.DeepEqual(expCrossRefs, out.CrossRefs) {
+		t.Errorf("cross references mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", expCrossRefs, out.CrossRefs)
+	}
+}
+
+// Ensure notes nested in spans, in headings and before the first verse are
+// output.
+func TestRender_NestedNotes(t *testing.T) {
+	s := `\id GEN \c 1 \s1 T1\f + \ft N1\f* \p \f + \ft N2\f* \v 1 \wj T2\f + \ft N3\f*\wj* \v 2 \wj T3\x - \xt Joh 1:1\x*\wj*`
+	var buf bytes.Buffer
+	if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Footnotes []json.Footnote
+		CrossRefs []json.CrossReference `json:"crossReferences"`
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for _, f := range out.Footnotes {
+		got = append(got, f.OSIS+" "+f.Text)
+	}
+	for _, x := range out.CrossRefs {
+		got = append(got, x.OSIS+" "+x.Text)
+	}
+	if exp := []string{"GEN.1 N1", "GEN.1 N2", "GEN.1.1 N3", "GEN.1.2 Joh 1:1"}; !reflect.DeepEqual(exp, got) {
+		t.Errorf("notes mismatch: exp=%q got=%q", exp, got)
+	}
+}
+
+// Ensure extended notes and sidebars can be filtered by category.
+func TestRender_Categories(t *testing.T) {
+	s := `\id GEN \c 1 \p \v 1 T1\ef - \cat People\cat*\ft T2\ef* T3\f + \ft T4\f*\ex - \cat Places\cat*\xt Joh 1:1\ex*
+\esb \cat History\cat* \p T5 \esbe \esb \cat People\cat* \p T6 \esbe`
+	var tests = []struct {
+		categories []string
+		exp        []string
+	}{
+		{exp: []string{"footnote People", "footnote ", "crossReference Places", "sidebar History", "sidebar People"}},
+		{categories: []string{"people"}, exp: []string{"footnote People", "footnote ", "sidebar People"}},
+	}
+
+	for i, tt := range tests {
+		var buf bytes.Buffer
+		if _, err := json.NewRenderer(json.Options{Categories: tt.categories}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+			t.Errorf("%d. unexpected error: %v", i, err)
+			continue
+		}
+
+		var out struct {
+			Footnotes []struct{ Category string }
+			CrossRefs []struct{ Category string } `json:"crossReferences"`
+			Sidebars  []struct{ Category string }
+		}
+		if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+			t.Errorf("%d. unexpected error: %v", i, err)
+			continue
+		}
+		var got []string
+		for _, f := range out.Footnotes {
+			got = append(got, "footnote "+f.Category)
+		}
+		for _, x := range out.CrossRefs {
+			got = append(got, "crossReference "+x.Category)
+		}
+		for _, s := range out.Sidebars {
+			got = append(got, "sidebar "+s.Category)
+		}
+		if !reflect.DeepEqual(tt.exp, got) {
+			t.Errorf("%d. %q: mismatch: exp=%q got=%q", i, tt.categories, tt.exp, got)
+		}
+	}
+}
+
+// Ensure peripheral divisions are indexed as sections.
+func TestRender_Sections(t *testing.T) {
+	s := `\id FRT \h Front Matter \periph Title Page|id="title" \mt1 T1 \periph Foreword \p T2`
+	var buf bytes.Buffer
+	if _, err := json.NewRenderer(json.Options{}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Book  struct{ Peripheral bool }
+		Index map[int]struct {
+			OSIS string
+			Name string
+			Type string
+		}
+		Sections []struct {
+			OSIS       string
+			Title      string
+			Paragraphs []struct{ Marker, Text string }
+		}
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !out.Book.Peripheral {
+		t.Errorf("expected a peripheral book")
+	}
+	var got []string
+	for i := 1; i <= len(out.Index); i++ {
+		got = append(got, out.Index[i].Type+" "+out.Index[i].OSIS+" "+out.Index[i].Name)
+	}
+	if exp := []string{"book FRT Front Matter", "section FRT.title Title Page", "section FRT.2 Foreword"}; !reflect.DeepEqual(exp, got) {
+		t.Errorf("index mismatch: exp=%q got=%q", exp, got)
+	}
+	got = nil
+	for _, s := range out.Sections {
+		for _, p := range s.Paragraphs {
+			got = append(got, s.OSIS+" "+p.Marker+" "+p.Text)
+		}
+	}
+	if exp := []string{"FRT.title mt1 T1", "FRT.2 p T2"}; !reflect.DeepEqual(exp, got) {
+		t.Errorf("sections mismatch: exp=%q got=%q", exp, got)
+	}
+}
+
+// Ensure paragraphs and headings defined by a stylesheet are indexed.
+func TestRender_Stylesheet(t *testing.T) {
+	sty, err := parser.ParseStylesheet(strings.NewReader(`\Marker zp
+\StyleType Paragraph
+\TextType VerseText
+
+\Marker zs
+\StyleType Paragraph
+\TextType Section`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	s := `\id GEN \c 1 \zs T1 \zp \v 1 T2 \v 2 T3`
+	if _, err := json.NewRenderer(json.Options{Stylesheet: sty}, strings.NewReader(s)).Render(&buf, 0, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out struct {
+		Index      map[int]json.IndexItem
+		Headings   []json.Heading
+		Paragraphs []json.Paragraph
+	}
+	if err := encjson.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got []string
+	for i := 1; i <= len(out.Index); i++ {
+		got = append(got, out.Index[i].Type+" "+out.Index[i].OSIS)
+	}
+	if exp := []string{"book GEN", "chapter GEN.1", "verse GEN.1.1", "verse GEN.1.2"}; !reflect.DeepEqual(exp, got) {
+		t.Errorf("index mismatch: exp=%q got=%q", exp, got)
+	}
+	if len(out.Headings) != 1 || out.Headings[0].Marker != "zs" || out.Headings[0].OSIS != "GEN.1.1" {
+		t.Errorf("unexpected headings: %+v", out.Headings)
+	}
+	if len(out.Paragraphs) != 1 || out.Paragraphs[0].Marker != "zp" || out.Paragraphs[0].OSIS != "GEN.1.1" {
+		t.Errorf("unexpected paragraphs: %+v", out.Paragraphs)
+	}
+}
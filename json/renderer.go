@@ -1,6 +1,10 @@
 package json
 
-import "io"
+import (
+	"io"
+
+	"github.com/socceroos/usfm/parser"
+)
 
 // Renderer render the parsed content
 type Renderer interface {
@@ -10,4 +14,11 @@ type Renderer interface {
 // Options for rendering
 type Options struct {
 	Title string
+
+	// Categories lists the categories (\cat) of the extended notes and
+	// sidebars to output; all are output if empty
+	Categories []string
+
+	// Stylesheet adds the markers of a Paratext stylesheet to the parser
+	Stylesheet *parser.Stylesheet
 }
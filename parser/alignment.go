@@ -0,0 +1,89 @@
+package parser
+
+import "strconv"
+
+// SourceWord is an original language word of an alignment milestone
+// (\zaln-s |x-strong="G2316" x-lemma="θεός"\*).
+type SourceWord struct {
+	// Strong is the Strong's number (x-strong)
+	Strong string
+
+	// Lemma is the dictionary form of the word (x-lemma)
+	Lemma string
+
+	// Morph is the morphology code (x-morph)
+	Morph string
+
+	// Text is the word as written in the source text (x-content)
+	Text string
+
+	// Occurrence is the occurrence of the word in the verse (x-occurrence)
+	Occurrence int
+
+	// Occurrences is the number of times the word occurs in the verse
+	// (x-occurrences)
+	Occurrences int
+}
+
+// AlignedWord is a target word (\w) and the source words it is aligned to.
+type AlignedWord struct {
+	// Text is the target word
+	Text string
+
+	// Occurrence is the occurrence of the word in the verse (x-occurrence)
+	Occurrence int
+
+	// Occurrences is the number of times the word occurs in the verse
+	// (x-occurrences)
+	Occurrences int
+
+	// Sources lists the source words of the enclosing \zaln-s milestones,
+	// outermost first (empty if the word is not aligned)
+	Sources []SourceWord
+}
+
+// AlignedVerse holds the aligned words of a verse.
+type AlignedVerse struct {
+	// Reference is the OSIS ID of the verse (e.g. "TIT.1.1")
+	Reference string
+
+	// Words lists the target words of the verse in order
+	Words []AlignedWord
+}
+
+// Interlinear returns the target words of every verse below node with the
+// source words they are aligned to by \zaln-s ... \zaln-e milestones.
+func Interlinear(node *Content) []AlignedVerse {
+	var verses []AlignedVerse
+	Inspect(node, func(c *Cursor) bool {
+		if !c.Node.IsMarker("w") || c.Verse == "" {
+			return !c.Node.IsNote()
+		}
+
+		word := AlignedWord{Text: textOf(c.Node)}
+		word.Occurrence, _ = strconv.Atoi(c.Node.Attributes["x-occurrence"])
+		word.Occurrences, _ = strconv.Atoi(c.Node.Attributes["x-occurrences"])
+		for _, m := range c.Milestones() {
+			if m.Name == "zaln" {
+				word.Sources = append(word.Sources, sourceWord(m.Attributes()))
+			}
+		}
+
+		ref := c.Reference()
+		if n := len(verses); n == 0 || verses[n-1].Reference != ref {
+			verses = append(verses, AlignedVerse{Reference: ref})
+		}
+		verses[len(verses)-1].Words = append(verses[len(verses)-1].Words, word)
+		return false
+	})
+	return verses
+}
+
+// sourceWord returns the source word described by the attributes of an
+// alignment milestone.
+func sourceWord(attrs map[string]string) SourceWord {
+	w := SourceWord{Strong: attrs["x-strong"], Lemma: attrs["x-lemma"], Morph: attrs["x-morph"], Text: attrs["x-content"]}
+	w.Occurrence, _ = strconv.Atoi(attrs["x-occurrence"])
+	w.Occurrences, _ = strconv.Atoi(attrs["x-occurrences"])
+	return w
+}
@@ -0,0 +1,41 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure target words are mapped to the source words of their alignments.
+func TestInterlinear(t *testing.T) {
+	s := `\id TIT \c 1 \p
+\v 1 \zaln-s |x-strong="G39720" x-lemma="Παῦλος" x-morph="Gr,N,,,,,NMS," x-occurrence="1" x-occurrences="1" x-content="Παῦλος"\*\w Paul|x-occurrence="1" x-occurrences="1"\w*\zaln-e\*,
+\zaln-s |x-strong="G14010" x-lemma="δοῦλος" x-content="δοῦλος"\*\w a\w* \w servant\w*\zaln-e\*
+\w of|x-occurrence="1" x-occurrences="2"\w*
+\zaln-s |x-strong="G23160" x-lemma="θεός"\*\zaln-s |x-strong="G35880" x-lemma="ὁ"\*\w God\w*\zaln-e\*\zaln-e\*
+\v 2 \zaln-s |x-strong="G1909" x-lemma="ἐπί"\*\w with\w*\zaln-e\*`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	paul := parser.SourceWord{Strong: "G39720", Lemma: "Παῦλος", Morph: "Gr,N,,,,,NMS,", Text: "Παῦλος", Occurrence: 1, Occurrences: 1}
+	servant := parser.SourceWord{Strong: "G14010", Lemma: "δοῦλος", Text: "δοῦλος"}
+	exp := []parser.AlignedVerse{
+		{Reference: "TIT.1.1", Words: []parser.AlignedWord{
+			{Text: "Paul", Occurrence: 1, Occurrences: 1, Sources: []parser.SourceWord{paul}},
+			{Text: "a", Sources: []parser.SourceWord{servant}},
+			{Text: "servant", Sources: []parser.SourceWord{servant}},
+			{Text: "of", Occurrence: 1, Occurrences: 2},
+			{Text: "God", Sources: []parser.SourceWord{{Strong: "G23160", Lemma: "θεός"}, {Strong: "G35880", Lemma: "ὁ"}}},
+		}},
+		{Reference: "TIT.1.2", Words: []parser.AlignedWord{
+			{Text: "with", Sources: []parser.SourceWord{{Strong: "G1909", Lemma: "ἐπί"}}},
+		}},
+	}
+	if got := parser.Interlinear(content); !reflect.DeepEqual(exp, got) {
+		t.Errorf("interlinear mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+}
@@ -1,5 +1,109 @@
 package parser
 
+import "strings"
+
+// NodeKind is the kind of a Content node. It is stored in the Type field as
+// a string so the JSON form of Content stays the same.
+type NodeKind int
+
+const (
+	// UnknownNode is a node with a Type not listed below
+	UnknownNode NodeKind = iota
+
+	// BookNode is the root node of a parsed book
+	BookNode
+
+	// MarkerNode is a marker such as \p, \v or \wj; the Value is the marker
+	MarkerNode
+
+	// TextNode is plain text
+	TextNode
+
+	// BookCodeNode is the book code after \id
+	BookCodeNode
+
+	// ChapterNumberNode is the number after \c
+	ChapterNumberNode
+
+	// VerseNumberNode is the number after \v
+	VerseNumberNode
+
+	// SubverseNode marks the continuation of a verse in a new paragraph or
+	// poetry line
+	SubverseNode
+
+	// HeadingNode is the text of \h
+	HeadingNode
+
+	// DescriptionNode is the text of \d
+	DescriptionNode
+
+	// SpeakerNode is the text of \sp
+	SpeakerNode
+
+	// CitationNode is the attribute list of a character marker
+	CitationNode
+
+	// CallerNode is the caller of a footnote or cross reference
+	CallerNode
+
+	// ReferenceNode is a parsed cross reference target; the Value is an OSIS ID
+	ReferenceNode
+
+	// TableNode groups the \tr rows of a table
+	TableNode
+
+	// IntroductionNode groups the introduction markers of a book (\imt, \is,
+	// \ip, \io etc. up to \ie)
+	IntroductionNode
+
+	// ListNode groups consecutive list markers (\lh, \li#, \lf and \lim#)
+	ListNode
+
+	// SidebarNode groups the markers of a study bible sidebar (\esb ...
+	// \esbe); the category is in the "category" attribute
+	SidebarNode
+
+	// PeripheralNode groups a \periph divider of a peripheral book (e.g. FRT)
+	// and the markers of its division
+	PeripheralNode
+
+	// UnknownMarkerNode is a marker missing from the registry, kept with its
+	// raw text (e.g. `\zx`) and the content up to its closing marker, if any
+	UnknownMarkerNode
+)
+
+var nodeKinds = []string{
+	UnknownNode:       "",
+	BookNode:          "book",
+	MarkerNode:        "marker",
+	TextNode:          "text",
+	BookCodeNode:      "bookcode",
+	ChapterNumberNode: "chapternumber",
+	VerseNumberNode:   "versenumber",
+	SubverseNode:      "subverse",
+	HeadingNode:       "heading",
+	DescriptionNode:   "description",
+	SpeakerNode:       "speaker",
+	CitationNode:      "citation",
+	CallerNode:        "caller",
+	ReferenceNode:     "reference",
+	TableNode:         "table",
+	IntroductionNode:  "introduction",
+	ListNode:          "list",
+	SidebarNode:       "sidebar",
+	PeripheralNode:    "peripheral",
+	UnknownMarkerNode: "unknown-marker",
+}
+
+// String returns the Type string of the kind.
+func (k NodeKind) String() string {
+	if k < 0 || int(k) >= len(nodeKinds) {
+		return ""
+	}
+	return nodeKinds[k]
+}
+
 // Content represents a part of source
 // It could be a marker or text
 type Content struct {
@@ -12,6 +116,169 @@ type Content struct {
 	// The byte position of the marker
 	Position int
 
+	// Span is the source range covered by the node and its children
+	Span Span `json:"-"`
+
+	// Attributes holds the USFM 3 attributes of a marker (e.g. lemma, strong)
+	Attributes map[string]string `json:",omitempty"`
+
 	// Children point to the child contents (empty if no child)
 	Children []*Content
+
+	// markers classifies the markers of a parse with a stylesheet (nil for
+	// the USFM 3 markers)
+	markers *Registry
+}
+
+// registry returns the markers the node was parsed with.
+func (c *Content) registry() *Registry {
+	if c.markers != nil {
+		return c.markers
+	}
+	return defaultMarkers
+}
+
+// setMarkers sets the registry of a node and its descendants.
+func setMarkers(c *Content, markers *Registry) {
+	c.markers = markers
+	for _, child := range c.Children {
+		setMarkers(child, markers)
+	}
+}
+
+// isCustom reports whether a marker was added by a stylesheet (e.g. \zp).
+func isCustom(def *MarkerDef) bool {
+	known, _ := defaultMarkers.Lookup(def.Name)
+	return known == nil
+}
+
+// Kind returns the kind of the node.
+func (c *Content) Kind() NodeKind {
+	for k, typ := range nodeKinds {
+		if k != int(UnknownNode) && typ == c.Type {
+			return NodeKind(k)
+		}
+	}
+	return UnknownNode
+}
+
+// Marker returns the marker name without the backslash (e.g. "p" for \p),
+// or an empty string if the node is not a marker.
+func (c *Content) Marker() string {
+	if c.Type != MarkerNode.String() {
+		return ""
+	}
+	return strings.TrimPrefix(c.Value, "\\")
+}
+
+// IsMarker reports whether the node is the given marker (e.g. "v").
+func (c *Content) IsMarker(name string) bool {
+	return c.Type == MarkerNode.String() && c.Marker() == name
+}
+
+// IsParagraph reports whether the node is a paragraph marker such as \p, ¶,
+// \m or \q1.
+func (c *Content) IsParagraph() bool {
+	return c.Type == MarkerNode.String() && (c.Value == "¶" || c.registry().Kind(c.Value) == ParagraphKind)
+}
+
+// IsNote reports whether the node is a footnote, endnote or cross reference
+// marker such as \f or \x.
+func (c *Content) IsNote() bool {
+	return c.Type == MarkerNode.String() && c.registry().Kind(c.Value) == NoteKind
+}
+
+// ChapterNumber returns the chapter number of a \c node, or an empty string
+// if the node is not a chapter.
+func (c *Content) ChapterNumber() string {
+	if !c.IsMarker("c") {
+		return ""
+	}
+	for _, child := range c.Children {
+		if child.Type == ChapterNumberNode.String() {
+			return child.Value
+		}
+	}
+	return ""
+}
+
+// VerseNumber returns the verse number of a \v node, or an empty string if
+// the node is not a verse.
+func (c *Content) VerseNumber() string {
+	if !c.IsMarker("v") {
+		return ""
+	}
+	for _, child := range c.Children {
+		if child.Type == VerseNumberNode.String() {
+			return child.Value
+		}
+	}
+	return ""
+}
+
+// IsContinuation reports whether a \v node continues a verse started in an
+// earlier paragraph or poetry line.
+func (c *Content) IsContinuation() bool {
+	if !c.IsMarker("v") {
+		return false
+	}
+	for _, child := range c.Children {
+		if child.Type == SubverseNode.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// VerseRange returns the parsed verse number of a \v node.
+func (c *Content) VerseRange() (VerseRange, error) {
+	return ParseVerseRange(c.VerseNumber())
+}
+
+// AltNumber returns the alternate number (\va or \ca) of a \v or \c node,
+// or an empty string if there is none.
+func (c *Content) AltNumber() string {
+	return c.childText(map[string]string{"v": "va", "c": "ca"})
+}
+
+// PublishedNumber returns the published number (\vp or \cp) of a \v or \c
+// node, or an empty string if there is none.
+func (c *Content) PublishedNumber() string {
+	return c.childText(map[string]string{"v": "vp", "c": "cp"})
+}
+
+// childText returns the text of the first child marker named in markers for
+// the marker of the node.
+func (c *Content) childText(markers map[string]string) string {
+	name, ok := markers[c.Marker()]
+	if !ok {
+		return ""
+	}
+	for _, child := range c.Children {
+		if child.IsMarker(name) {
+			return textOf(child)
+		}
+	}
+	return ""
+}
+
+// Location is a position in the source.
+type Location struct {
+	// Offset is the byte offset from the start of the source
+	Offset int
+
+	// Line is the line number, starting at 1
+	Line int
+
+	// Column is the column in runes, starting at 1
+	Column int
+
+	// Column16 is the column in UTF-16 code units, starting at 1
+	Column16 int
+}
+
+// Span is a range of the source from Start up to (not including) End.
+type Span struct {
+	Start Location
+	End   Location
 }
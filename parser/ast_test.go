@@ -0,0 +1,76 @@
+package parser_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure the node helpers describe the parsed nodes.
+func TestContentHelpers(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 2 \w grace|strong="H2603"\w* text`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content.Kind() != parser.BookNode {
+		t.Errorf("book kind mismatch: got=%v", content.Kind())
+	}
+
+	markerC := content.Children[1]
+	if !markerC.IsMarker("c") || markerC.Marker() != "c" || markerC.IsParagraph() {
+		t.Errorf("chapter marker mismatch: %#v", markerC)
+	}
+	if markerC.Children[0].Kind() != parser.ChapterNumberNode || markerC.Children[0].Marker() != "" {
+		t.Errorf("chapter number mismatch: %#v", markerC.Children[0])
+	}
+
+	markerP := content.Children[2]
+	if !markerP.IsParagraph() {
+		t.Errorf("expected \\p to be a paragraph")
+	}
+
+	markerV := markerP.Children[0]
+	if markerV.VerseNumber() != "2" || markerP.VerseNumber() != "" {
+		t.Errorf("verse number mismatch: got=%q", markerV.VerseNumber())
+	}
+
+	markerW := markerV.Children[1]
+	if markerW.Marker() != "w" || markerW.Attributes["strong"] != "H2603" {
+		t.Errorf("attribute mismatch: %#v", markerW)
+	}
+	if markerV.Children[2].Kind() != parser.TextNode || markerV.Children[2].Kind().String() != "text" {
+		t.Errorf("text kind mismatch: %#v", markerV.Children[2])
+	}
+	if (&parser.Content{Type: "other"}).Kind() != parser.UnknownNode {
+		t.Errorf("expected an unknown kind")
+	}
+}
+
+// Ensure the JSON form of Content keeps the Type string, and adds only the
+// Attributes key of markers with attributes.
+func TestContentJSON(t *testing.T) {
+	var tests = []struct {
+		c    *parser.Content
+		json string
+	}{
+		{
+			c:    &parser.Content{Type: parser.MarkerNode.String(), Value: `\w`, Attributes: map[string]string{"lemma": "grace"}},
+			json: `{"Type":"marker","Value":"\\w","Position":0,"Attributes":{"lemma":"grace"},"Children":null}`,
+		},
+		{
+			c:    &parser.Content{Type: parser.TextNode.String(), Value: "T1", Position: 4, Span: parser.Span{End: parser.Location{Offset: 6}}},
+			json: `{"Type":"text","Value":"T1","Position":4,"Children":null}`,
+		},
+	}
+
+	for i, tt := range tests {
+		b, err := json.Marshal(tt.c)
+		if err != nil {
+			t.Errorf("%d. unexpected error: %v", i, err)
+		} else if string(b) != tt.json {
+			t.Errorf("%d. JSON mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.json, b)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// attributePair matches a key="value" attribute
+var attributePair = regexp.MustCompile(`([\w-]+)\s*=\s*"([^"]*)"`)
+
+// ParseAttributes parses a USFM 3 attribute list such as
+// `|lemma="grace" strong="H2603"` into a map. A value without a name
+// (`|grace`) is stored under the default attribute of the marker.
+func ParseAttributes(text string, defaultAttribute string) map[string]string {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "|"))
+	if text == "" {
+		return nil
+	}
+
+	attrs := map[string]string{}
+	pairs := attributePair.FindAllStringSubmatch(text, -1)
+	if len(pairs) == 0 {
+		if defaultAttribute != "" {
+			attrs[defaultAttribute] = text
+		}
+		return attrs
+	}
+	for _, pair := range pairs {
+		attrs[pair[1]] = pair[2]
+	}
+	return attrs
+}
@@ -0,0 +1,28 @@
+package parser_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure attribute lists are parsed into maps.
+func TestParseAttributes(t *testing.T) {
+	var tests = []struct {
+		s     string
+		def   string
+		attrs map[string]string
+	}{
+		{s: `|lemma="grace" strong="H2603" x-morph="He,Vqp3ms"`, def: "lemma", attrs: map[string]string{"lemma": "grace", "strong": "H2603", "x-morph": "He,Vqp3ms"}},
+		{s: `|grace`, def: "lemma", attrs: map[string]string{"lemma": "grace"}},
+		{s: `|src="avnt016.jpg" size="span"`, def: "src", attrs: map[string]string{"src": "avnt016.jpg", "size": "span"}},
+		{s: `|`, def: "lemma", attrs: nil},
+	}
+
+	for i, tt := range tests {
+		if got := parser.ParseAttributes(tt.s, tt.def); !reflect.DeepEqual(tt.attrs, got) {
+			t.Errorf("%d. %q: exp=%v got=%v", i, tt.s, tt.attrs, got)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// BookInfo describes a book by its USFM code.
+type BookInfo struct {
+	// Code is the three character USFM book code
+	Code string
+
+	// Name is the English name of the book
+	Name string
+}
+
+// Books lists the scripture books in canonical order.
+var Books = []BookInfo{
+	{"GEN", "Genesis"}, {"EXO", "Exodus"}, {"LEV", "Leviticus"}, {"NUM", "Numbers"},
+	{"DEU", "Deuteronomy"}, {"JOS", "Joshua"}, {"JDG", "Judges"}, {"RUT", "Ruth"},
+	{"1SA", "1 Samuel"}, {"2SA", "2 Samuel"}, {"1KI", "1 Kings"}, {"2KI", "2 Kings"},
+	{"1CH", "1 Chronicles"}, {"2CH", "2 Chronicles"}, {"EZR", "Ezra"}, {"NEH", "Nehemiah"},
+	{"EST", "Esther"}, {"JOB", "Job"}, {"PSA", "Psalms"}, {"PRO", "Proverbs"},
+	{"ECC", "Ecclesiastes"}, {"SNG", "Song of Songs"}, {"ISA", "Isaiah"}, {"JER", "Jeremiah"},
+	{"LAM", "Lamentations"}, {"EZK", "Ezekiel"}, {"DAN", "Daniel"}, {"HOS", "Hosea"},
+	{"JOL", "Joel"}, {"AMO", "Amos"}, {"OBA", "Obadiah"}, {"JON", "Jonah"},
+	{"MIC", "Micah"}, {"NAM", "Nahum"}, {"HAB", "Habakkuk"}, {"ZEP", "Zephaniah"},
+	{"HAG", "Haggai"}, {"ZEC", "Zechariah"}, {"MAL", "Malachi"},
+	{"MAT", "Matthew"}, {"MRK", "Mark"}, {"LUK", "Luke"}, {"JHN", "John"},
+	{"ACT", "Acts"}, {"ROM", "Romans"}, {"1CO", "1 Corinthians"}, {"2CO", "2 Corinthians"},
+	{"GAL", "Galatians"}, {"EPH", "Ephesians"}, {"PHP", "Philippians"}, {"COL", "Colossians"},
+	{"1TH", "1 Thessalonians"}, {"2TH", "2 Thessalonians"}, {"1TI", "1 Timothy"}, {"2TI", "2 Timothy"},
+	{"TIT", "Titus"}, {"PHM", "Philemon"}, {"HEB", "Hebrews"}, {"JAS", "James"},
+	{"1PE", "1 Peter"}, {"2PE", "2 Peter"}, {"1JN", "1 John"}, {"2JN", "2 John"},
+	{"3JN", "3 John"}, {"JUD", "Jude"}, {"REV", "Revelation"},
+	{"TOB", "Tobit"}, {"JDT", "Judith"}, {"ESG", "Esther Greek"}, {"WIS", "Wisdom of Solomon"},
+	{"SIR", "Sirach"}, {"BAR", "Baruch"}, {"LJE", "Letter of Jeremiah"}, {"S3Y", "Song of the 3 Young Men"},
+	{"SUS", "Susanna"}, {"BEL", "Bel and the Dragon"}, {"1MA", "1 Maccabees"}, {"2MA", "2 Maccabees"},
+	{"3MA", "3 Maccabees"}, {"4MA", "4 Maccabees"}, {"1ES", "1 Esdras"}, {"2ES", "2 Esdras"},
+	{"MAN", "Prayer of Manasses"}, {"PS2", "Psalm 151"}, {"ODA", "Odes"}, {"PSS", "Psalms of Solomon"},
+	{"EZA", "Apocalypse of Ezra"}, {"5EZ", "5 Ezra"}, {"6EZ", "6 Ezra"}, {"DAG", "Daniel Greek"},
+	{"LAO", "Laodiceans"},
+}
+
+// PeripheralBooks lists the books of non-scripture content.
+var PeripheralBooks = []BookInfo{
+	{"FRT", "Front Matter"}, {"INT", "Introductions"}, {"BAK", "Back Matter"}, {"CNC", "Concordance"},
+	{"GLO", "Glossary"}, {"TDX", "Topical Index"}, {"NDX", "Names Index"}, {"OTH", "Other"},
+	{"XXA", "Extra A"}, {"XXB", "Extra B"}, {"XXC", "Extra C"}, {"XXD", "Extra D"},
+	{"XXE", "Extra E"}, {"XXF", "Extra F"}, {"XXG", "Extra G"},
+}
+
+// IsPeripheral reports whether the book code is a peripheral book such as
+// "FRT" or "GLO".
+func IsPeripheral(code string) bool {
+	for _, b := range PeripheralBooks {
+		if strings.EqualFold(b.Code, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// bookAbbreviations holds common abbreviations which are not a prefix of the book name.
+var bookAbbreviations = map[string]string{
+	"jdg": "JDG", "jgs": "JDG", "ps": "PSA", "pss": "PSA", "sng": "SNG", "song": "SNG",
+	"ezk": "EZK", "jl": "JOL", "mt": "MAT", "mk": "MRK", "mrk": "MRK", "lk": "LUK",
+	"jn": "JHN", "jhn": "JHN", "php": "PHP", "phm": "PHM", "jas": "JAS", "jud": "JUD",
+	"1jn": "1JN", "2jn": "2JN", "3jn": "3JN",
+}
+
+// LookupBook returns the USFM book code for a book code, name or abbreviation
+// such as "MAT", "Matthew", "Mat." or "1 Cor". It returns an empty string if
+// the book is unknown.
+func LookupBook(name string) string {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '.' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+	if key == "" {
+		return ""
+	}
+
+	if code, ok := bookAbbreviations[key]; ok {
+		return code
+	}
+	for _, b := range Books {
+		if strings.EqualFold(b.Code, key) {
+			return b.Code
+		}
+	}
+	for _, b := range Books {
+		if strings.HasPrefix(strings.ToLower(strings.Replace(b.Name, " ", "", -1)), key) {
+			return b.Code
+		}
+	}
+	return ""
+}
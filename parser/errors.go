@@ -0,0 +1,93 @@
+package parser
+
+import "fmt"
+
+// Severity is the severity of a Diagnostic.
+type Severity int
+
+const (
+	// SeverityError represents a problem which stops the parse (unless lenient)
+	SeverityError Severity = iota
+
+	// SeverityWarning represents a problem the parser worked around
+	SeverityWarning
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic codes
+const (
+	// CodeExpectedBookCode is reported when '\id' is not followed by a book code
+	CodeExpectedBookCode = "expected-book-code"
+
+	// CodeExpectedChapterNumber is reported when '\c' is not followed by a number
+	CodeExpectedChapterNumber = "expected-chapter-number"
+
+	// CodeExpectedVerseNumber is reported when '\v' is not followed by a number
+	CodeExpectedVerseNumber = "expected-verse-number"
+
+	// CodeUnclosedMarker is reported when a character marker or note is not closed
+	CodeUnclosedMarker = "unclosed-marker"
+
+	// CodeUnmatchedMilestone is reported when a milestone start has no end or
+	// an end has no start
+	CodeUnmatchedMilestone = "unmatched-milestone"
+
+	// CodeUnmatchedMarker is reported when a closing character marker has no
+	// open marker to close
+	CodeUnmatchedMarker = "unmatched-marker"
+
+	// CodeUnknownMarker is reported when a marker is not in the registry; it
+	// is kept as an "unknown-marker" node
+	CodeUnknownMarker = "unknown-marker"
+)
+
+// Diagnostic describes a problem found while parsing.
+type Diagnostic struct {
+	// Severity of the problem
+	Severity Severity
+
+	// Code identifies the kind of problem (e.g. CodeExpectedVerseNumber)
+	Code string
+
+	// Message is a human readable description
+	Message string
+
+	// Span is the source range of the offending token
+	Span Span
+
+	// Expected lists the tokens which would have been valid
+	Expected []Token
+
+	// Found is the token which was found
+	Found Token
+
+	// Literal is the literal value of the token which was found
+	Literal string
+}
+
+// String returns the diagnostic with its source location.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", d.Span.Start.Line, d.Span.Start.Column, d.Severity, d.Message, d.Code)
+}
+
+// ParseError is returned by Parse and holds the error diagnostics found.
+type ParseError struct {
+	Diagnostics []Diagnostic
+}
+
+// Error returns the message of the first diagnostic.
+func (e *ParseError) Error() string {
+	if len(e.Diagnostics) == 0 {
+		return "parse error"
+	} else if len(e.Diagnostics) > 1 {
+		return fmt.Sprintf("%s (and %d more errors)", e.Diagnostics[0].Message, len(e.Diagnostics)-1)
+	}
+	return e.Diagnostics[0].Message
+}
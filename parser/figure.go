@@ -0,0 +1,99 @@
+package parser
+
+import "strings"
+
+// Figure describes an illustration marker (\fig ...\fig*).
+type Figure struct {
+	// Caption is the caption text of the figure
+	Caption string
+
+	// Description is the description of the illustration (alt, or DESC in
+	// USFM 2)
+	Description string
+
+	// Source is the file name of the illustration (src, or FILE in USFM 2)
+	Source string
+
+	// Size is the relative size of the illustration: "col" or "span"
+	Size string
+
+	// Location is the range of references the illustration may be placed in
+	// (loc)
+	Location string
+
+	// Copyright is the copyright information of the illustration (copy)
+	Copyright string
+
+	// Reference is the reference the caption refers to (ref, e.g. "1.18")
+	Reference string
+}
+
+// figureFields lists the attributes of the pipe separated USFM 2 form
+// (\fig DESC|FILE|SIZE|LOC|COPY|CAP|REF\fig*) following the description.
+var figureFields = []string{"src", "size", "loc", "copy", "", "ref"}
+
+// Figure returns the illustration described by a \fig marker. It returns
+// false if the node is not a figure.
+func (c *Content) Figure() (Figure, bool) {
+	def, _ := c.registry().Lookup(c.Marker())
+	if def == nil || def.Name != "fig" {
+		return Figure{}, false
+	}
+
+	var words []string
+	Inspect(c, func(cur *Cursor) bool {
+		if cur.Node.Kind() == TextNode {
+			words = append(words, cur.Node.Value)
+		}
+		return !cur.Node.IsNote()
+	})
+	return Figure{
+		Caption:     strings.Join(words, " "),
+		Description: c.Attributes["alt"],
+		Source:      c.Attributes["src"],
+		Size:        c.Attributes["size"],
+		Location:    c.Attributes["loc"],
+		Copyright:   c.Attributes["copy"],
+		Reference:   c.Attributes["ref"],
+	}, true
+}
+
+// isFigure2 reports whether the attribute list of a \fig marker is in the
+// pipe separated USFM 2 form.
+func isFigure2(lit string) bool {
+	return strings.Count(lit, "|") == len(figureFields) && !attributePair.MatchString(lit)
+}
+
+// parseFigure2 converts the pipe separated fields of a USFM 2 figure into
+// the USFM 3 attributes of the marker. The text read so far is the
+// description, it is replaced by the caption.
+func (p *Parser) parseFigure2(marker *Content, citation *Content) {
+	var desc []string
+	var children []*Content
+	for _, child := range marker.Children {
+		if child.Kind() == TextNode {
+			desc = append(desc, child.Value)
+		} else if child != citation {
+			children = append(children, child)
+		}
+	}
+
+	marker.Attributes = map[string]string{}
+	if len(desc) > 0 {
+		marker.Attributes["alt"] = strings.Join(desc, " ")
+	}
+	fields := strings.Split(strings.TrimPrefix(strings.TrimSpace(citation.Value), "|"), "|")
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		} else if figureFields[i] != "" {
+			marker.Attributes[figureFields[i]] = field
+			continue
+		}
+		for _, word := range strings.Fields(field) {
+			children = append(children, newNode(TextNode, word, citation.Span))
+		}
+	}
+	marker.Children = append(children, citation)
+}
@@ -0,0 +1,53 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure figures are parsed in both the USFM 2 and USFM 3 syntax.
+func TestContent_Figure(t *testing.T) {
+	var tests = []struct {
+		s   string
+		fig parser.Figure
+	}{
+		{
+			s:   `\fig At once they left their nets.|avnt016.jpg|span|||Simon and Andrew|1.18\fig*`,
+			fig: parser.Figure{Caption: "Simon and Andrew", Description: "At once they left their nets.", Source: "avnt016.jpg", Size: "span", Reference: "1.18"},
+		},
+		{
+			s:   `\fig |a.jpg|col|1.1-2|© ABS||\fig*`,
+			fig: parser.Figure{Source: "a.jpg", Size: "col", Location: "1.1-2", Copyright: "© ABS"},
+		},
+		{
+			s:   `\fig Fishing|src="fish.jpg" size="col" ref="1:19" alt="Men fishing"\fig*`,
+			fig: parser.Figure{Caption: "Fishing", Description: "Men fishing", Source: "fish.jpg", Size: "col", Reference: "1:19"},
+		},
+		{
+			s:   `\fig Fishing|fish.jpg\fig*`,
+			fig: parser.Figure{Caption: "Fishing", Source: "fish.jpg"},
+		},
+	}
+
+	for i, tt := range tests {
+		content, err := parser.NewParser(strings.NewReader(`\id MRK \c 1 \p \v 18 T1 ` + tt.s)).Parse()
+		if err != nil {
+			t.Errorf("%d. %q: unexpected error: %v", i, tt.s, err)
+			continue
+		}
+
+		var got []parser.Figure
+		parser.Inspect(content, func(c *parser.Cursor) bool {
+			if fig, ok := c.Node.Figure(); ok {
+				got = append(got, fig)
+			}
+			return true
+		})
+		if !reflect.DeepEqual([]parser.Figure{tt.fig}, got) {
+			t.Errorf("%d. %q: figure mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", i, tt.s, tt.fig, got)
+		}
+	}
+}
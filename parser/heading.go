@@ -0,0 +1,77 @@
+package parser
+
+import "strings"
+
+// Heading describes a section heading marker such as \ms1, \s2 or \sd1.
+type Heading struct {
+	// Style is the marker name without level: "ms", "s" or "sd"
+	Style string
+
+	// Level is the level of the heading (1 for \s and \s1)
+	Level int
+
+	// Text is the text of the heading without notes and references
+	Text string
+
+	// References lists the OSIS IDs of the \mr, \sr and \r references
+	// attached to the heading
+	References []string
+}
+
+// isHeading reports whether the marker name (without level) is a section
+// heading.
+func isHeading(name string) bool {
+	switch name {
+	case "ms", "s", "sd":
+		return true
+	}
+	return false
+}
+
+// isSectionReference reports whether the marker name is a reference which
+// belongs to the preceding section heading.
+func isSectionReference(name string) bool {
+	switch name {
+	case "mr", "sr", "r":
+		return true
+	}
+	return false
+}
+
+// Heading returns the section heading described by a heading marker. It
+// returns false if the node is not a section heading.
+func (c *Content) Heading() (Heading, bool) {
+	return c.headingIn(c.registry())
+}
+
+// headingIn returns the section heading described by a heading marker of the
+// given registry. Section headings defined by a stylesheet are of level 1.
+func (c *Content) headingIn(markers *Registry) (Heading, bool) {
+	def, level := markers.Lookup(c.Marker())
+	if def == nil || !(isHeading(def.Name) || isCustom(def) && def.Kind == ParagraphKind && def.TextType == TextSection) {
+		return Heading{}, false
+	} else if level == 0 {
+		level = 1
+	}
+
+	heading := Heading{Style: def.Name, Level: level}
+	var words []string
+	for _, child := range c.Children {
+		if name := child.Marker(); isSectionReference(name) {
+			for _, ref := range child.Children {
+				if ref.Kind() == ReferenceNode {
+					heading.References = append(heading.References, ref.Value)
+				}
+			}
+			continue
+		}
+		Inspect(child, func(cur *Cursor) bool {
+			if cur.Node.Kind() == TextNode {
+				words = append(words, cur.Node.Value)
+			}
+			return !cur.Node.IsNote()
+		})
+	}
+	heading.Text = strings.Join(words, " ")
+	return heading, true
+}
@@ -0,0 +1,51 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure section headings keep their text and the references attached to them.
+func TestParserHeadings(t *testing.T) {
+	s := `\id PSA \ms1 Book One \mr (Psalms 1–41) \c 1 \s1 True Happiness\f + \ft Or blessing\f* \sr 1:1-6 \q1 \v 1 T1 \s2 Sub \r (Jer 17:5-8) \q1 \v 2 T2 \sd1 \s3 Third \s4 Fourth`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []parser.Heading
+	for _, c := range content.Children {
+		if h, ok := c.Heading(); ok {
+			got = append(got, h)
+		}
+	}
+	exp := []parser.Heading{
+		{Style: "ms", Level: 1, Text: "Book One", References: []string{"PSA.1-PSA.41"}},
+		{Style: "s", Level: 1, Text: "True Happiness", References: []string{"PSA.1.1-PSA.1.6"}},
+		{Style: "s", Level: 2, Text: "Sub", References: []string{"JER.17.5-JER.17.8"}},
+		{Style: "sd", Level: 1},
+		{Style: "s", Level: 3, Text: "Third"},
+		{Style: "s", Level: 4, Text: "Fourth"},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("headings mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+}
+
+// Ensure a reference without a preceding heading stays a paragraph of its own.
+func TestParserHeadings_Reference(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id MAT \c 1 \r (Luk 3:23-38) \p \v 1 T1`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := content.Children[2]
+	if !r.IsMarker("r") {
+		t.Fatalf("expected a reference, got %q", r.Value)
+	}
+	if ref := r.Children[len(r.Children)-1]; ref.Kind() != parser.ReferenceNode || ref.Value != "LUK.3.23-LUK.3.38" {
+		t.Errorf("unexpected reference: %s %q", ref.Type, ref.Value)
+	}
+}
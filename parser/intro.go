@@ -0,0 +1,69 @@
+package parser
+
+import "strings"
+
+// OutlineEntry is an \io# entry of a book introduction outline.
+type OutlineEntry struct {
+	// Level is the level of the entry (1 for \io and \io1)
+	Level int
+
+	// Text is the text of the entry without the reference
+	Text string
+
+	// Reference is the text of the \ior reference (e.g. "1.1-2.12")
+	Reference string
+
+	// OSIS lists the OSIS IDs of the reference
+	OSIS []string
+}
+
+// isIntroduction reports whether the marker belongs to a book introduction.
+func isIntroduction(def *MarkerDef) bool {
+	switch def.Name {
+	case "imt", "imte", "is":
+		return true
+	}
+	return def.Kind == ParagraphKind && def.TextType == TextIntro
+}
+
+// Outline returns the outline entries of an introduction node.
+func (c *Content) Outline() []OutlineEntry {
+	var entries []OutlineEntry
+	for _, child := range c.Children {
+		def, level := child.registry().Lookup(child.Marker())
+		if def == nil || def.Name != "io" {
+			continue
+		}
+		if level == 0 {
+			level = 1
+		}
+
+		entry := OutlineEntry{Level: level}
+		var words []string
+		for _, part := range child.Children {
+			if part.Kind() == TextNode {
+				words = append(words, part.Value)
+			} else if part.IsMarker("ior") {
+				entry.Reference = textOf(part)
+				for _, ref := range part.Children {
+					if ref.Kind() == ReferenceNode {
+						entry.OSIS = append(entry.OSIS, ref.Value)
+					}
+				}
+			}
+		}
+		entry.Text = strings.Join(words, " ")
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// introduction returns the open introduction of the book, or adds a new one.
+// An introduction is closed by \ie.
+func (p *Parser) introduction(book *Content, span Span) *Content {
+	if p.openIntro == nil {
+		p.openIntro = newNode(IntroductionNode, "", Span{Start: span.Start, End: span.Start})
+		book.Children = append(book.Children, p.openIntro)
+	}
+	return p.openIntro
+}
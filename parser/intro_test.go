@@ -0,0 +1,60 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure introduction markers are grouped into an introduction node.
+func TestParserIntroduction(t *testing.T) {
+	s := `\id GAL \imt1 Introduction \is Author \ip Paul wrote \bk Galatians\bk*. \iot Outline \io1 Greeting \ior 1.1-10\ior* \io2 Gospel \ior 1.11–2.21\ior* \ie \c 1 \p \v 1 T1`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var types []string
+	for _, c := range content.Children {
+		types = append(types, c.Type+" "+c.Value)
+	}
+	if exp := []string{`marker \id`, "introduction ", `marker \c`, `marker \p`}; !reflect.DeepEqual(exp, types) {
+		t.Fatalf("book mismatch: exp=%q got=%q", exp, types)
+	}
+
+	intro := content.Children[1]
+	var markers []string
+	for _, c := range intro.Children {
+		markers = append(markers, c.Value)
+	}
+	if exp := []string{`\imt1`, `\is`, `\ip`, `\iot`, `\io1`, `\io2`, `\ie`}; !reflect.DeepEqual(exp, markers) {
+		t.Errorf("introduction mismatch: exp=%q got=%q", exp, markers)
+	}
+
+	exp := []parser.OutlineEntry{
+		{Level: 1, Text: "Greeting", Reference: "1.1-10", OSIS: []string{"GAL.1.1-GAL.1.10"}},
+		{Level: 2, Text: "Gospel", Reference: "1.11–2.21", OSIS: []string{"GAL.1.11-GAL.2.21"}},
+	}
+	if got := intro.Outline(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("outline mismatch:\n\nexp=%#v\n\ngot=%#v\n\n", exp, got)
+	}
+}
+
+// Ensure \ie closes an introduction.
+func TestParserIntroductionEnd(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id GAL \ip T1 \ip T2 \ie \ip T3`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(content.Children); n != 3 {
+		t.Fatalf("expected 3 children, got %d", n)
+	}
+	if intro := content.Children[1]; len(intro.Children) != 3 {
+		t.Errorf("expected 3 markers in the first introduction, got %d", len(intro.Children))
+	}
+	if intro := content.Children[2]; intro.Kind() != parser.IntroductionNode || len(intro.Children) != 1 {
+		t.Errorf("expected a second introduction, got %q", intro.Type)
+	}
+}
@@ -0,0 +1,70 @@
+package parser
+
+// ListItem describes a list marker such as \lh, \li2 or \lim1.
+type ListItem struct {
+	// Style is the marker name without level: "lh", "li", "lf" or "lim"
+	Style string
+
+	// Level is the level of \li# and \lim# items (1 for \li and \li1), 0 for
+	// list headers and footers
+	Level int
+
+	// Embedded is true for embedded list items (\lim#)
+	Embedded bool
+
+	// Key is the text of the \lik key of the item
+	Key string
+
+	// Values lists the text of the \liv# values of the item in order
+	Values []string
+}
+
+// isListItem reports whether the marker name (without level) is a list
+// header, item or footer.
+func isListItem(name string) bool {
+	switch name {
+	case "lh", "li", "lf", "lim":
+		return true
+	}
+	return false
+}
+
+// ListItem returns the list item described by a list marker. It returns false
+// if the node is not a list header, item or footer.
+func (c *Content) ListItem() (ListItem, bool) {
+	def, level := c.registry().Lookup(c.Marker())
+	if def == nil || !isListItem(def.Name) {
+		return ListItem{}, false
+	}
+
+	item := ListItem{Style: def.Name, Embedded: def.Name == "lim"}
+	if def.Levels > 0 {
+		item.Level = level
+		if level == 0 {
+			item.Level = 1
+		}
+	}
+	Inspect(c, func(cur *Cursor) bool {
+		def, _ := cur.Node.registry().Lookup(cur.Node.Marker())
+		if def == nil {
+			return true
+		} else if def.Name == "lik" {
+			item.Key = textOf(cur.Node)
+			return false
+		} else if def.Name == "liv" {
+			item.Values = append(item.Values, textOf(cur.Node))
+			return false
+		}
+		return !cur.Node.IsNote()
+	})
+	return item, true
+}
+
+// list returns the list being read, or adds a new one to the book.
+func (p *Parser) list(book *Content, span Span) *Content {
+	if p.openList == nil {
+		p.openList = newNode(ListNode, "", Span{Start: span.Start, End: span.Start})
+		book.Children = append(book.Children, p.openList)
+	}
+	return p.openList
+}
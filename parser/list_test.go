@@ -0,0 +1,49 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure consecutive list markers are grouped into a list node.
+func TestParserList(t *testing.T) {
+	s := `\id NUM \c 7 \lh Offerings: \li1 \v 12 \lik Day 1\lik* \liv1 Nahshon\liv1* \liv2 Judah\liv2* \li2 T2 \lim1 T3 \lf Total \p T4 \li T5`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var types []string
+	for _, c := range content.Children {
+		types = append(types, c.Type+" "+c.Value)
+	}
+	if exp := []string{`marker \id`, `marker \c`, "list ", `marker \p`, "list "}; !reflect.DeepEqual(exp, types) {
+		t.Fatalf("book mismatch: exp=%q got=%q", exp, types)
+	}
+
+	var got []parser.ListItem
+	for _, c := range content.Children[2].Children {
+		item, ok := c.ListItem()
+		if !ok {
+			t.Fatalf("unexpected list child: %q", c.Value)
+		}
+		got = append(got, item)
+	}
+	exp := []parser.ListItem{
+		{Style: "lh"},
+		{Style: "li", Level: 1, Key: "Day 1", Values: []string{"Nahshon", "Judah"}},
+		{Style: "li", Level: 2},
+		{Style: "lim", Level: 1, Embedded: true},
+		{Style: "lf"},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("items mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+
+	if item, _ := content.Children[4].Children[0].ListItem(); item.Level != 1 {
+		t.Errorf("expected level 1 for \\li, got %d", item.Level)
+	}
+}
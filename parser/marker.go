@@ -0,0 +1,405 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MarkerKind classifies a marker by the way it affects the document structure.
+type MarkerKind int
+
+const (
+	// UnknownKind represents a marker that is not in the registry
+	UnknownKind MarkerKind = iota
+
+	// HeaderKind represents book header markers (\id, \h, \toc1 etc.)
+	HeaderKind
+
+	// ParagraphKind represents markers which start a new block of text
+	ParagraphKind
+
+	// CharacterKind represents markers which apply to a span of text
+	CharacterKind
+
+	// NoteKind represents footnote and cross-reference markers
+	NoteKind
+
+	// MilestoneKind represents self-closing milestone markers (\qt-s\*)
+	MilestoneKind
+
+	// ChapterKind represents the '\c' marker
+	ChapterKind
+
+	// VerseKind represents the '\v' marker
+	VerseKind
+)
+
+// TextType describes what kind of text follows a marker.
+type TextType int
+
+const (
+	// TextOther represents text which is neither scripture nor a heading
+	TextOther TextType = iota
+
+	// TextVerse represents scripture text
+	TextVerse
+
+	// TextTitle represents book and introduction titles
+	TextTitle
+
+	// TextSection represents section headings
+	TextSection
+
+	// TextIntro represents book introduction text
+	TextIntro
+
+	// TextNote represents footnote and cross-reference text
+	TextNote
+)
+
+// MarkerDef describes a USFM marker known to the scanner and parser.
+type MarkerDef struct {
+	// Name is the marker without the backslash or level number (e.g. "q" for \q2)
+	Name string
+
+	// Kind is the structural kind of the marker
+	Kind MarkerKind
+
+	// TextType is the kind of text which follows the marker
+	TextType TextType
+
+	// Closed is true if the marker must be closed with a matching '\name*'
+	Closed bool
+
+	// Levels is the highest numbered variant (4 for \q1-\q4), 0 if unnumbered
+	Levels int
+
+	// OccursUnder lists the markers this marker may be nested in (empty for anywhere)
+	OccursUnder []string
+
+	// DefaultAttribute is the attribute named by an unnamed value (\w word|grace\w*)
+	DefaultAttribute string
+
+	// Tokens lists the dedicated tokens by level, the last one is reused for
+	// deeper levels. Empty for markers scanned as the generic Marker token.
+	Tokens []Token
+
+	// EndToken is the dedicated token for the closing marker (Illegal for none)
+	EndToken Token
+}
+
+// Token returns the token the scanner emits for the given level of the marker.
+func (d *MarkerDef) Token(level int) Token {
+	if len(d.Tokens) == 0 {
+		return Marker
+	}
+	if level < 1 {
+		level = 1
+	}
+	if level > len(d.Tokens) {
+		level = len(d.Tokens)
+	}
+	return d.Tokens[level-1]
+}
+
+// Registry holds the marker definitions used by the Scanner and Parser.
+type Registry struct {
+	defs map[string]*MarkerDef
+}
+
+// NewRegistry returns an empty marker registry.
+func NewRegistry() *Registry {
+	return &Registry{defs: map[string]*MarkerDef{}}
+}
+
+// DefaultRegistry returns a new registry holding the USFM 3 marker set.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for i := range usfmMarkers {
+		def := usfmMarkers[i]
+		r.Register(&def)
+	}
+	return r
+}
+
+// Register adds or replaces a marker definition.
+func (r *Registry) Register(def *MarkerDef) {
+	r.defs[strings.ToLower(def.Name)] = def
+}
+
+// Lookup finds the definition of a marker name such as "q2", "toc1",
+// "qt1-s" or "tc1-2" (without the backslash or closing '*'). It returns the
+// definition and the level of the marker, or nil if the marker is unknown.
+func (r *Registry) Lookup(name string) (*MarkerDef, int) {
+	name = strings.ToLower(name)
+	if def, ok := r.defs[name]; ok {
+		return def, 0
+	}
+
+	// Milestones carry their level before the -s/-e suffix (\qt1-s)
+	var suffix string
+	if i := strings.LastIndex(name, "-"); i > 0 {
+		name, suffix = name[:i], name[i:]
+	}
+
+	base := strings.TrimRightFunc(name, unicode.IsDigit)
+	if base == name || base == "" {
+		return nil, 0
+	}
+	def, ok := r.defs[base+suffix]
+	span := 0
+	if !ok && suffix != "" {
+		// Table cells may span columns (\tc1-2)
+		if n, err := strconv.Atoi(suffix[1:]); err == nil {
+			def, ok = r.defs[base]
+			span = n
+		}
+	}
+	if !ok || def.Levels == 0 {
+		return nil, 0
+	}
+	level, err := strconv.Atoi(name[len(base):])
+	if err != nil || level < 1 || level > def.Levels {
+		return nil, 0
+	} else if span != 0 && (span <= level || span > def.Levels || !isTableCell(def.Name)) {
+		return nil, 0
+	}
+	return def, level
+}
+
+// Kind returns the kind of a marker literal such as `\q2` or `\wj*`.
+func (r *Registry) Kind(lit string) MarkerKind {
+	name, _ := splitMarker(lit)
+	if def, _ := r.Lookup(name); def != nil {
+		return def.Kind
+	}
+	return UnknownKind
+}
+
+// CanOccurUnder reports whether the marker may be nested in the parent marker
+// according to the default nesting rules.
+func (r *Registry) CanOccurUnder(name, parent string) bool {
+	def, _ := r.Lookup(name)
+	if def == nil {
+		return false
+	}
+	if len(def.OccursUnder) == 0 {
+		return true
+	}
+	parentDef, _ := r.Lookup(parent)
+	if parentDef == nil {
+		return false
+	}
+	for _, under := range def.OccursUnder {
+		if under == parentDef.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitMarker splits a marker literal into its name and whether it is a
+// closing marker, e.g. `\wj*` gives ("wj", true). The name of a nested
+// character marker does not include the plus (`\+nd` gives "nd").
+func splitMarker(lit string) (name string, end bool) {
+	name = strings.TrimPrefix(strings.TrimPrefix(lit, `\`), "+")
+	if strings.HasSuffix(name, "*") {
+		return strings.TrimSuffix(name, "*"), true
+	}
+	return name, false
+}
+
+// isNested reports whether a marker literal is a character marker nested in
+// another character marker (`\+nd`).
+func isNested(lit string) bool {
+	return strings.HasPrefix(lit, `\+`)
+}
+
+// Default nesting rules shared by groups of markers.
+var (
+	underBook       = []string{"id"}
+	underParagraphs = []string{"p", "m", "po", "pr", "cls", "pmo", "pm", "pmc", "pmr", "pi", "mi", "nb", "pc", "ph", "q", "qr", "qc", "qa", "qm", "qd", "li", "lh", "lf", "lim", "d", "sp", "tr", "th", "thr", "tc", "tcr", "ip", "ipi", "im", "imi", "ipq", "imq", "ipr", "iq", "ili", "io", "iot", "is", "imt", "ms", "mr", "s", "sr", "r", "mt", "mte", "lit", "sd"}
+	underFootnotes  = []string{"f", "fe", "ef"}
+	underXrefs      = []string{"x", "ex"}
+)
+
+// usfmMarkers is the USFM 3 marker set.
+var usfmMarkers = []MarkerDef{
+	// Identification
+	{Name: "id", Kind: HeaderKind, Tokens: []Token{MarkerID}, Levels: 1},
+	{Name: "usfm", Kind: HeaderKind, OccursUnder: underBook},
+	{Name: "ide", Kind: HeaderKind, Tokens: []Token{MarkerIde}, OccursUnder: underBook},
+	{Name: "sts", Kind: HeaderKind, OccursUnder: underBook},
+	{Name: "rem", Kind: HeaderKind},
+	{Name: "h", Kind: HeaderKind, Tokens: []Token{MarkerH}, Levels: 3, OccursUnder: underBook},
+	{Name: "toc", Kind: HeaderKind, Levels: 3, OccursUnder: underBook},
+	{Name: "toca", Kind: HeaderKind, Levels: 3, OccursUnder: underBook},
+
+	// Introductions
+	{Name: "imt", Kind: ParagraphKind, TextType: TextTitle, Levels: 4},
+	{Name: "is", Kind: ParagraphKind, TextType: TextSection, Levels: 2},
+	{Name: "ip", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "ipi", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "im", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "imi", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "ipq", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "imq", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "ipr", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "iq", Kind: ParagraphKind, TextType: TextIntro, Levels: 3},
+	{Name: "ib", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "ili", Kind: ParagraphKind, TextType: TextIntro, Levels: 2},
+	{Name: "iot", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "io", Kind: ParagraphKind, TextType: TextIntro, Levels: 4},
+	{Name: "ior", Kind: CharacterKind, TextType: TextIntro, Closed: true},
+	{Name: "iqt", Kind: CharacterKind, TextType: TextIntro, Closed: true},
+	{Name: "iex", Kind: ParagraphKind, TextType: TextIntro},
+	{Name: "imte", Kind: ParagraphKind, TextType: TextTitle, Tokens: []Token{MarkerImte1}, Levels: 2},
+	{Name: "ie", Kind: ParagraphKind, TextType: TextIntro},
+
+	// Titles, headings and labels
+	{Name: "mt", Kind: ParagraphKind, TextType: TextTitle, Levels: 4},
+	{Name: "mte", Kind: ParagraphKind, TextType: TextTitle, Levels: 2},
+	{Name: "ms", Kind: ParagraphKind, TextType: TextSection, Levels: 3},
+	{Name: "mr", Kind: ParagraphKind, TextType: TextSection},
+	{Name: "s", Kind: ParagraphKind, TextType: TextSection, Tokens: []Token{MarkerS}, Levels: 4},
+	{Name: "sr", Kind: ParagraphKind, TextType: TextSection},
+	{Name: "r", Kind: ParagraphKind, TextType: TextSection},
+	{Name: "rq", Kind: CharacterKind, Closed: true},
+	{Name: "d", Kind: ParagraphKind, TextType: TextSection, Tokens: []Token{MarkerD}},
+	{Name: "sp", Kind: ParagraphKind, TextType: TextSection, Tokens: []Token{MarkerSP}},
+	{Name: "sd", Kind: ParagraphKind, Levels: 4},
+
+	// Chapters and verses
+	{Name: "c", Kind: ChapterKind, Tokens: []Token{MarkerC}},
+	{Name: "ca", Kind: CharacterKind, Closed: true},
+	{Name: "cl", Kind: ParagraphKind},
+	{Name: "cp", Kind: ParagraphKind},
+	{Name: "cd", Kind: ParagraphKind},
+	{Name: "v", Kind: VerseKind, Tokens: []Token{MarkerV}},
+	{Name: "va", Kind: CharacterKind, Closed: true},
+	{Name: "vp", Kind: CharacterKind, Closed: true},
+
+	// Paragraphs
+	{Name: "p", Kind: ParagraphKind, TextType: TextVerse, Tokens: []Token{MarkerP}},
+	{Name: "m", Kind: ParagraphKind, TextType: TextVerse, Tokens: []Token{MarkerM}},
+	{Name: "po", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "pr", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "cls", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "pmo", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "pm", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "pmc", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "pmr", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "pi", Kind: ParagraphKind, TextType: TextVerse, Levels: 3},
+	{Name: "mi", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "nb", Kind: ParagraphKind, TextType: TextVerse, Tokens: []Token{MarkerNB}},
+	{Name: "pc", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "ph", Kind: ParagraphKind, TextType: TextVerse, Levels: 3},
+	{Name: "b", Kind: ParagraphKind, Tokens: []Token{MarkerB}},
+	{Name: "pb", Kind: ParagraphKind},
+	{Name: "lit", Kind: ParagraphKind},
+
+	// Poetry
+	{Name: "q", Kind: ParagraphKind, TextType: TextVerse, Tokens: []Token{MarkerQ1, MarkerQ2, MarkerQ3, MarkerQ4}, Levels: 4},
+	{Name: "qr", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "qc", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "qs", Kind: CharacterKind, Closed: true, Tokens: []Token{MarkerQS}, EndToken: EndMarkerQS},
+	{Name: "qa", Kind: ParagraphKind},
+	{Name: "qac", Kind: CharacterKind, Closed: true},
+	{Name: "qm", Kind: ParagraphKind, TextType: TextVerse, Levels: 3},
+	{Name: "qd", Kind: ParagraphKind, TextType: TextVerse},
+
+	// Lists
+	{Name: "lh", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "li", Kind: ParagraphKind, TextType: TextVerse, Levels: 4},
+	{Name: "lf", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "lim", Kind: ParagraphKind, TextType: TextVerse, Levels: 4},
+	{Name: "litl", Kind: CharacterKind, Closed: true},
+	{Name: "lik", Kind: CharacterKind, Closed: true},
+	{Name: "liv", Kind: CharacterKind, Closed: true, Levels: 5},
+
+	// Tables
+	{Name: "tr", Kind: ParagraphKind, TextType: TextVerse},
+	{Name: "th", Kind: CharacterKind, Levels: 12, OccursUnder: []string{"tr"}},
+	{Name: "thr", Kind: CharacterKind, Levels: 12, OccursUnder: []string{"tr"}},
+	{Name: "thc", Kind: CharacterKind, Levels: 12, OccursUnder: []string{"tr"}},
+	{Name: "tc", Kind: CharacterKind, Levels: 12, OccursUnder: []string{"tr"}},
+	{Name: "tcr", Kind: CharacterKind, Levels: 12, OccursUnder: []string{"tr"}},
+	{Name: "tcc", Kind: CharacterKind, Levels: 12, OccursUnder: []string{"tr"}},
+
+	// Footnotes
+	{Name: "f", Kind: NoteKind, TextType: TextNote, Closed: true, Tokens: []Token{MarkerF}, EndToken: EndMarkerF, OccursUnder: underParagraphs},
+	{Name: "fe", Kind: NoteKind, TextType: TextNote, Closed: true, OccursUnder: underParagraphs},
+	{Name: "fr", Kind: CharacterKind, TextType: TextNote, Tokens: []Token{MarkerFR}, OccursUnder: underFootnotes},
+	{Name: "fq", Kind: CharacterKind, TextType: TextNote, OccursUnder: underFootnotes},
+	{Name: "fqa", Kind: CharacterKind, TextType: TextNote, OccursUnder: underFootnotes},
+	{Name: "fk", Kind: CharacterKind, TextType: TextNote, OccursUnder: underFootnotes},
+	{Name: "fl", Kind: CharacterKind, TextType: TextNote, OccursUnder: underFootnotes},
+	{Name: "fw", Kind: CharacterKind, TextType: TextNote, OccursUnder: underFootnotes},
+	{Name: "fp", Kind: CharacterKind, TextType: TextNote, OccursUnder: underFootnotes},
+	{Name: "ft", Kind: CharacterKind, TextType: TextNote, Tokens: []Token{MarkerFT}, OccursUnder: underFootnotes},
+	{Name: "fv", Kind: CharacterKind, TextType: TextNote, Closed: true, OccursUnder: underFootnotes},
+	{Name: "fdc", Kind: CharacterKind, TextType: TextNote, Closed: true, OccursUnder: underFootnotes},
+	{Name: "fm", Kind: CharacterKind, TextType: TextNote, Closed: true},
+
+	// Cross references
+	{Name: "x", Kind: NoteKind, TextType: TextNote, Closed: true, Tokens: []Token{MarkerX}, EndToken: EndMarkerX, OccursUnder: underParagraphs},
+	{Name: "xo", Kind: CharacterKind, TextType: TextNote, Tokens: []Token{MarkerXO}, OccursUnder: underXrefs},
+	{Name: "xk", Kind: CharacterKind, TextType: TextNote, OccursUnder: underXrefs},
+	{Name: "xq", Kind: CharacterKind, TextType: TextNote, OccursUnder: underXrefs},
+	{Name: "xt", Kind: CharacterKind, TextType: TextNote, Tokens: []Token{MarkerXT}, DefaultAttribute: "link-href"},
+	{Name: "xta", Kind: CharacterKind, TextType: TextNote, OccursUnder: underXrefs},
+	{Name: "xop", Kind: CharacterKind, TextType: TextNote, Closed: true, OccursUnder: underXrefs},
+	{Name: "xot", Kind: CharacterKind, TextType: TextNote, Closed: true},
+	{Name: "xnt", Kind: CharacterKind, TextType: TextNote, Closed: true},
+	{Name: "xdc", Kind: CharacterKind, TextType: TextNote, Closed: true},
+
+	// Special text
+	{Name: "add", Kind: CharacterKind, Closed: true, Tokens: []Token{MarkerAdd}, EndToken: EndMarkerAdd},
+	{Name: "bk", Kind: CharacterKind, Closed: true},
+	{Name: "dc", Kind: CharacterKind, Closed: true},
+	{Name: "k", Kind: CharacterKind, Closed: true},
+	{Name: "nd", Kind: CharacterKind, Closed: true},
+	{Name: "ord", Kind: CharacterKind, Closed: true},
+	{Name: "pn", Kind: CharacterKind, Closed: true},
+	{Name: "png", Kind: CharacterKind, Closed: true},
+	{Name: "addpn", Kind: CharacterKind, Closed: true},
+	{Name: "qt", Kind: CharacterKind, Closed: true},
+	{Name: "sig", Kind: CharacterKind, Closed: true},
+	{Name: "sls", Kind: CharacterKind, Closed: true},
+	{Name: "tl", Kind: CharacterKind, Closed: true},
+	{Name: "wj", Kind: CharacterKind, Closed: true, Tokens: []Token{MarkerWJ}, EndToken: EndMarkerWJ},
+	{Name: "em", Kind: CharacterKind, Closed: true},
+	{Name: "bd", Kind: CharacterKind, Closed: true},
+	{Name: "it", Kind: CharacterKind, Closed: true},
+	{Name: "bdit", Kind: CharacterKind, Closed: true},
+	{Name: "no", Kind: CharacterKind, Closed: true},
+	{Name: "sc", Kind: CharacterKind, Closed: true},
+	{Name: "sup", Kind: CharacterKind, Closed: true},
+	{Name: "pro", Kind: CharacterKind, Closed: true},
+	{Name: "rb", Kind: CharacterKind, Closed: true, DefaultAttribute: "gloss"},
+	{Name: "w", Kind: CharacterKind, Closed: true, Tokens: []Token{MarkerW}, EndToken: EndMarkerW, DefaultAttribute: "lemma"},
+	{Name: "wg", Kind: CharacterKind, Closed: true},
+	{Name: "wh", Kind: CharacterKind, Closed: true},
+	{Name: "wa", Kind: CharacterKind, Closed: true},
+	{Name: "fig", Kind: CharacterKind, Closed: true, Tokens: []Token{MarkerFig}, EndToken: EndMarkerFig, DefaultAttribute: "src"},
+	{Name: "jmp", Kind: CharacterKind, Closed: true, DefaultAttribute: "link-href"},
+	{Name: "ndx", Kind: CharacterKind, Closed: true},
+
+	// Study bible content and peripherals
+	{Name: "ef", Kind: NoteKind, TextType: TextNote, Closed: true},
+	{Name: "ex", Kind: NoteKind, TextType: TextNote, Closed: true},
+	{Name: "cat", Kind: CharacterKind, Closed: true},
+	{Name: "esb", Kind: ParagraphKind},
+	{Name: "esbe", Kind: ParagraphKind},
+	{Name: "periph", Kind: ParagraphKind, DefaultAttribute: "id"},
+
+	// Milestones
+	{Name: "qt-s", Kind: MilestoneKind, Levels: 5, DefaultAttribute: "who"},
+	{Name: "qt-e", Kind: MilestoneKind, Levels: 5},
+	{Name: "ts", Kind: MilestoneKind},
+	{Name: "ts-s", Kind: MilestoneKind},
+	{Name: "ts-e", Kind: MilestoneKind},
+	{Name: "zaln-s", Kind: MilestoneKind},
+	{Name: "zaln-e", Kind: MilestoneKind},
+}
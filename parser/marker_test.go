@@ -0,0 +1,65 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure the registry resolves marker names and levels.
+func TestRegistryLookup(t *testing.T) {
+	var tests = []struct {
+		name  string
+		def   string
+		kind  parser.MarkerKind
+		level int
+	}{
+		{name: "p", def: "p", kind: parser.ParagraphKind},
+		{name: "q", def: "q", kind: parser.ParagraphKind},
+		{name: "q3", def: "q", kind: parser.ParagraphKind, level: 3},
+		{name: "toc1", def: "toc", kind: parser.HeaderKind, level: 1},
+		{name: "mt2", def: "mt", kind: parser.ParagraphKind, level: 2},
+		{name: "nd", def: "nd", kind: parser.CharacterKind},
+		{name: "fqa", def: "fqa", kind: parser.CharacterKind},
+		{name: "x", def: "x", kind: parser.NoteKind},
+		{name: "qt1-s", def: "qt-s", kind: parser.MilestoneKind, level: 1},
+		{name: "ts-e", def: "ts-e", kind: parser.MilestoneKind},
+		{name: "tc1-2", def: "tc", kind: parser.CharacterKind, level: 1},
+		{name: "q5"},
+		{name: "nd1"},
+		{name: "tc2-1"},
+		{name: "q1-2"},
+		{name: "zfoo"},
+	}
+
+	r := parser.DefaultRegistry()
+	for i, tt := range tests {
+		def, level := r.Lookup(tt.name)
+		if def == nil {
+			if tt.def != "" {
+				t.Errorf("%d. %q: expected %q, got nil", i, tt.name, tt.def)
+			}
+			continue
+		}
+		if def.Name != tt.def || def.Kind != tt.kind || level != tt.level {
+			t.Errorf("%d. %q: exp=%s/%d/%d got=%s/%d/%d", i, tt.name, tt.def, tt.kind, tt.level, def.Name, def.Kind, level)
+		}
+	}
+}
+
+// Ensure the default nesting rules are applied.
+func TestRegistryCanOccurUnder(t *testing.T) {
+	r := parser.DefaultRegistry()
+	if !r.CanOccurUnder("fqa", "f") {
+		t.Errorf(`expected \fqa to occur under \f`)
+	}
+	if r.CanOccurUnder("fqa", "p") {
+		t.Errorf(`expected \fqa not to occur under \p`)
+	}
+	if !r.CanOccurUnder("nd", "q2") {
+		t.Errorf(`expected \nd to occur anywhere`)
+	}
+	if !r.CanOccurUnder("tc2", "tr") {
+		t.Errorf(`expected \tc2 to occur under \tr`)
+	}
+}
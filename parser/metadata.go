@@ -0,0 +1,137 @@
+package parser
+
+import "strings"
+
+// Title is a main title (\mt#) or ending title (\mte#) of a book.
+type Title struct {
+	// Level is the level of the title (1 for \mt and \mt1)
+	Level int
+
+	// Text is the text of the title
+	Text string
+}
+
+// Metadata holds the header information of a book.
+type Metadata struct {
+	// Code is the book code from \id
+	Code string
+
+	// Description is the text following the book code in \id
+	Description string
+
+	// Encoding is the character encoding from \ide
+	Encoding string
+
+	// USFMVersion is the USFM version from \usfm
+	USFMVersion string
+
+	// Status is the project status from \sts
+	Status string
+
+	// Remarks lists the \rem comments
+	Remarks []string
+
+	// Header is the running header from \h (or \h1)
+	Header string
+
+	// LongName is the long table of contents name from \toc1
+	LongName string
+
+	// ShortName is the short table of contents name from \toc2
+	ShortName string
+
+	// Abbreviation is the book abbreviation from \toc3
+	Abbreviation string
+
+	// Titles lists the main titles (\mt#) in order
+	Titles []Title
+
+	// EndingTitles lists the ending titles (\mte#) in order
+	EndingTitles []Title
+}
+
+// Name returns the name of the book: the long name, short name, running
+// header or main title, whichever is found first.
+func (m Metadata) Name() string {
+	for _, name := range []string{m.LongName, m.ShortName, m.Header} {
+		if name != "" {
+			return name
+		}
+	}
+	for _, t := range m.Titles {
+		if t.Level <= 1 {
+			return t.Text
+		}
+	}
+	return m.Code
+}
+
+// Metadata returns the header information of a book node. Markers after the
+// first chapter (except \mte) are ignored.
+func (c *Content) Metadata() Metadata {
+	m := Metadata{Code: c.Value}
+	chapter := false
+	for _, child := range c.Children {
+		def, level := child.registry().Lookup(child.Marker())
+		if def == nil {
+			continue
+		} else if level == 0 {
+			level = 1
+		}
+
+		text := headerText(child)
+		switch def.Name {
+		case "c":
+			chapter = true
+		case "mte":
+			m.EndingTitles = append(m.EndingTitles, Title{Level: level, Text: text})
+		}
+		if chapter {
+			continue
+		}
+
+		switch def.Name {
+		case "id":
+			if i := strings.Index(text, " "); i > 0 {
+				m.Description = text[i+1:]
+			}
+		case "ide":
+			m.Encoding = text
+		case "usfm":
+			m.USFMVersion = text
+		case "sts":
+			m.Status = text
+		case "rem":
+			m.Remarks = append(m.Remarks, text)
+		case "h":
+			if m.Header == "" {
+				m.Header = text
+			}
+		case "toc":
+			switch level {
+			case 1:
+				m.LongName = text
+			case 2:
+				m.ShortName = text
+			case 3:
+				m.Abbreviation = text
+			}
+		case "mt":
+			m.Titles = append(m.Titles, Title{Level: level, Text: text})
+		}
+	}
+	return m
+}
+
+// headerText joins the text below a header marker.
+func headerText(c *Content) string {
+	var words []string
+	Inspect(c, func(cur *Cursor) bool {
+		switch cur.Node.Kind() {
+		case TextNode, HeadingNode, BookCodeNode:
+			words = append(words, cur.Node.Value)
+		}
+		return !cur.Node.IsNote()
+	})
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,75 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure header markers are collected into the book metadata.
+func TestContent_Metadata(t *testing.T) {
+	s := `\id MAT 41MATGNT92.SFM, Good News Translation
+\ide UTF-8
+\usfm 3.0
+\sts 2
+\rem First remark
+\rem Second remark
+\h Matthew
+\toc1 The Gospel according to Matthew
+\toc2 Matthew
+\toc3 Mat
+\mt2 The Gospel
+\mt1 according to \bk Matthew\bk*
+\c 1
+\p \v 1 T1
+\mte1 The End`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := parser.Metadata{
+		Code:         "MAT",
+		Description:  "41MATGNT92.SFM, Good News Translation",
+		Encoding:     "UTF-8",
+		USFMVersion:  "3.0",
+		Status:       "2",
+		Remarks:      []string{"First remark", "Second remark"},
+		Header:       "Matthew",
+		LongName:     "The Gospel according to Matthew",
+		ShortName:    "Matthew",
+		Abbreviation: "Mat",
+		Titles:       []parser.Title{{Level: 2, Text: "The Gospel"}, {Level: 1, Text: "according to Matthew"}},
+		EndingTitles: []parser.Title{{Level: 1, Text: "The End"}},
+	}
+	if got := content.Metadata(); !reflect.DeepEqual(exp, got) {
+		t.Errorf("metadata mismatch:\n\nexp=%#v\n\ngot=%#v\n\n", exp, got)
+	}
+}
+
+// Ensure the book name falls back through the header markers.
+func TestMetadata_Name(t *testing.T) {
+	var tests = []struct {
+		s    string
+		name string
+	}{
+		{s: `\id MAT \h Matthew \toc1 The Gospel of Matthew \toc2 Matt`, name: "The Gospel of Matthew"},
+		{s: `\id MAT \h Matthew \toc2 Matt`, name: "Matt"},
+		{s: `\id MAT \h Matthew \mt1 Gospel`, name: "Matthew"},
+		{s: `\id MAT \mt2 The \mt1 Gospel`, name: "Gospel"},
+		{s: `\id MAT \c 1 \p \v 1 T1`, name: "MAT"},
+	}
+
+	for i, tt := range tests {
+		content, err := parser.NewParser(strings.NewReader(tt.s)).Parse()
+		if err != nil {
+			t.Errorf("%d. %q: unexpected error: %v", i, tt.s, err)
+			continue
+		}
+		if got := content.Metadata().Name(); got != tt.name {
+			t.Errorf("%d. %q: name mismatch: exp=%q got=%q", i, tt.s, tt.name, got)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Milestone is a pair of milestone markers such as \qt-s ... \qt-e, or a
+// standalone milestone such as \ts.
+type Milestone struct {
+	// Name is the milestone name without level and suffix (e.g. "qt")
+	Name string
+
+	// Level is the level of the milestone (0 if unnumbered)
+	Level int
+
+	// ID is the sid attribute of the start (or eid of an unmatched end)
+	ID string
+
+	// Start is the start marker (nil if the end was not matched)
+	Start *Content
+
+	// End is the end marker (nil if the start was not matched)
+	End *Content
+}
+
+// Who returns the speaker of a quotation milestone (the who attribute).
+func (m *Milestone) Who() string {
+	if m.Start == nil {
+		return ""
+	}
+	return m.Start.Attributes["who"]
+}
+
+// Attributes returns the attributes of the start marker.
+func (m *Milestone) Attributes() map[string]string {
+	if m.Start == nil {
+		return nil
+	}
+	return m.Start.Attributes
+}
+
+// milestoneMarker returns the name and level of a milestone marker node and
+// whether it starts (-s) or ends (-e) the milestone. It returns false if the
+// node is not a milestone.
+func milestoneMarker(markers *Registry, c *Content) (name string, level int, start, end, ok bool) {
+	def, level := markers.Lookup(c.Marker())
+	if def == nil || def.Kind != MilestoneKind {
+		return "", 0, false, false, false
+	}
+	start = strings.HasSuffix(def.Name, "-s")
+	end = strings.HasSuffix(def.Name, "-e")
+	name = strings.TrimSuffix(strings.TrimSuffix(def.Name, "-s"), "-e")
+	return name, level, start, end, true
+}
+
+// milestones pairs milestone markers in document order.
+type milestones struct {
+	markers *Registry // registry of the milestone markers (nil for USFM 3)
+	open    []*Milestone
+}
+
+// add adds a milestone marker. An end is paired with the open start with
+// the same ID, or else with the last open start of the same name and level.
+// It returns the milestone of the marker, and false for an end which matches
+// no open start.
+func (s *milestones) add(node *Content) (*Milestone, bool) {
+	markers := s.markers
+	if markers == nil {
+		markers = defaultMarkers
+	}
+	name, level, start, end, ok := milestoneMarker(markers, node)
+	if !ok {
+		return nil, false
+	} else if start {
+		m := &Milestone{Name: name, Level: level, ID: node.Attributes["sid"], Start: node}
+		s.open = append(s.open, m)
+		return m, true
+	} else if !end {
+		return &Milestone{Name: name, Level: level, ID: node.Attributes["sid"], Start: node, End: node}, true
+	}
+
+	eid := node.Attributes["eid"]
+	for i := len(s.open) - 1; i >= 0; i-- {
+		m := s.open[i]
+		if m.Name != name {
+			continue
+		} else if eid != "" && m.ID != "" && m.ID != eid {
+			continue
+		} else if (eid == "" || m.ID == "") && m.Level != level {
+			continue
+		}
+		m.End = node
+		s.open = append(s.open[:i], s.open[i+1:]...)
+		return m, true
+	}
+	return &Milestone{Name: name, Level: level, ID: eid, End: node}, false
+}
+
+// Milestones returns the milestones below node in document order, pairing
+// starts and ends across verses and paragraphs. Unmatched starts and ends are
+// returned with a nil End or Start.
+func Milestones(node *Content) []*Milestone {
+	var found []*Milestone
+	var s milestones
+	Inspect(node, func(c *Cursor) bool {
+		m, ok := s.add(c.Node)
+		if m != nil && (m.Start == c.Node || !ok) {
+			found = append(found, m)
+		}
+		return true
+	})
+	return found
+}
+
+// parseMilestone reads the attributes and the closing \* of a milestone and
+// pairs it with the open milestones.
+func (p *Parser) parseMilestone(marker *Content) {
+	tok, lit, span := p.scanIgnoreWhitespace()
+	if tok == Citation {
+		p.parseAttributes(marker, lit, span)
+		tok, _, span = p.scanIgnoreWhitespace()
+	}
+	if tok == EndMilestone {
+		marker.Span.End = span.End
+	} else {
+		p.unscan()
+	}
+
+	if _, ok := p.milestones.add(marker); !ok {
+		p.warn(Diagnostic{Code: CodeUnmatchedMilestone, Message: fmt.Sprintf("%s has no matching start", marker.Value), Span: marker.Span, Literal: marker.Value})
+	}
+}
+
+// closeMilestones warns about the milestones which were never ended.
+func (p *Parser) closeMilestones() {
+	for _, m := range p.milestones.open {
+		p.warn(Diagnostic{Code: CodeUnmatchedMilestone, Message: fmt.Sprintf("%s has no matching end", m.Start.Value), Span: m.Start.Span, Literal: m.Start.Value})
+	}
+	p.milestones.open = nil
+}
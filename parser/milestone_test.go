@@ -0,0 +1,82 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure milestone starts and ends are paired across verses and paragraphs.
+func TestParserMilestones(t *testing.T) {
+	s := `\id MAT \c 5 \ts\* \p \v 1 \qt-s |who="Jesus"\* T1 \zaln-s |x-strong="G3107"\*\w Blessed\w*\zaln-e\* \p \v 2 \qt1-s |sid="qt1_MAT_5:2" who="Crowd"\* T2 \qt-e\* T3 \qt1-e |eid="qt1_MAT_5:2"\*`
+	p := parser.NewParser(strings.NewReader(s))
+	content, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := p.Diagnostics(); len(d) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d)
+	}
+
+	type milestone struct {
+		Name, Start, End, ID, Who string
+	}
+	var got []milestone
+	for _, m := range parser.Milestones(content) {
+		got = append(got, milestone{Name: m.Name, Start: m.Start.Value, End: m.End.Value, ID: m.ID, Who: m.Who()})
+	}
+	exp := []milestone{
+		{Name: "ts", Start: `\ts`, End: `\ts`},
+		{Name: "qt", Start: `\qt-s`, End: `\qt-e`, Who: "Jesus"},
+		{Name: "zaln", Start: `\zaln-s`, End: `\zaln-e`},
+		{Name: "qt", Start: `\qt1-s`, End: `\qt1-e`, ID: "qt1_MAT_5:2", Who: "Crowd"},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("milestones mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+}
+
+// Ensure unmatched milestones are reported.
+func TestParserMilestones_Unmatched(t *testing.T) {
+	p := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 \qt-e\* T1 \qt-s |Jesus\* T2`))
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, d := range p.Diagnostics() {
+		got = append(got, d.Severity.String()+" "+d.Code+" "+d.Message)
+	}
+	exp := []string{
+		`warning unmatched-milestone \qt-e has no matching start`,
+		`warning unmatched-milestone \qt-s has no matching end`,
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("diagnostics mismatch: exp=%q got=%q", exp, got)
+	}
+}
+
+// Ensure the cursor reports the milestones open at each node.
+func TestCursor_Milestones(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 T1 \qt-s |Jesus\* T2 \p T3 \qt-e\* T4`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	parser.Inspect(content, func(c *parser.Cursor) bool {
+		if c.Node.Kind() == parser.TextNode {
+			who := ""
+			for _, m := range c.Milestones() {
+				who = m.Who()
+			}
+			got = append(got, c.Node.Value+":"+who)
+		}
+		return true
+	})
+	if exp := []string{"T1:", "T2:Jesus", "T3:Jesus", "T4:"}; !reflect.DeepEqual(exp, got) {
+		t.Errorf("quotes mismatch: exp=%q got=%q", exp, got)
+	}
+}
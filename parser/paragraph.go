@@ -0,0 +1,78 @@
+package parser
+
+// Paragraph describes a prose paragraph marker such as \p, \pi2 or \pmc.
+// Closure (\cls), liturgical (\lit) and page break (\pb) paragraphs are
+// told apart by their Style.
+type Paragraph struct {
+	// Style is the marker name without level ("p" for \p and ¶)
+	Style string
+
+	// Indent is the indent level of \pi#, \ph# and \mi (1 for \pi and \pi1),
+	// 0 for flush paragraphs
+	Indent int
+
+	// Align is "left", "right" (\pr, \pmr) or "center" (\pc, \pmc)
+	Align string
+
+	// Embedded is true for embedded text paragraphs (\pm, \pmo, \pmc, \pmr)
+	Embedded bool
+
+	// Hanging is true for paragraphs with a hanging indent (\ph#)
+	Hanging bool
+
+	// Continued is true for paragraphs which continue the previous one
+	// without a first line indent or break (\m, \mi, \nb)
+	Continued bool
+}
+
+// isParagraphStyle reports whether the marker name (without level) is a
+// prose paragraph.
+func isParagraphStyle(name string) bool {
+	switch name {
+	case "p", "m", "po", "pr", "cls", "pmo", "pm", "pmc", "pmr", "pi", "mi", "nb", "pc", "ph", "lit", "pb":
+		return true
+	}
+	return false
+}
+
+// Paragraph returns the paragraph style described by a paragraph marker. It
+// returns false if the node is not a prose paragraph.
+func (c *Content) Paragraph() (Paragraph, bool) {
+	if c.Type == MarkerNode.String() && c.Value == "¶" {
+		return Paragraph{Style: "p", Align: "left"}, true
+	}
+	def, level := c.registry().Lookup(c.Marker())
+	if def == nil {
+		return Paragraph{}, false
+	} else if isCustom(def) && def.Kind == ParagraphKind && def.TextType == TextVerse {
+		// Paragraphs of verse text defined by a stylesheet
+		return Paragraph{Style: def.Name, Align: "left"}, true
+	} else if !isParagraphStyle(def.Name) {
+		return Paragraph{}, false
+	}
+
+	para := Paragraph{Style: def.Name, Align: "left"}
+	switch def.Name {
+	case "pi", "ph":
+		para.Indent = level
+		if level == 0 {
+			para.Indent = 1
+		}
+		para.Hanging = def.Name == "ph"
+	case "mi":
+		para.Indent = 1
+	}
+	switch def.Name {
+	case "pr", "pmr":
+		para.Align = "right"
+	case "pc", "pmc":
+		para.Align = "center"
+	}
+	switch def.Name {
+	case "pm", "pmo", "pmc", "pmr":
+		para.Embedded = true
+	case "m", "mi", "nb":
+		para.Continued = true
+	}
+	return para, true
+}
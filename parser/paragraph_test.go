@@ -0,0 +1,52 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure paragraph markers describe their style.
+func TestContent_Paragraph(t *testing.T) {
+	var tests = []struct {
+		s    string
+		para parser.Paragraph
+		ok   bool
+	}{
+		{s: `\p`, para: parser.Paragraph{Style: "p", Align: "left"}, ok: true},
+		{s: `¶`, para: parser.Paragraph{Style: "p", Align: "left"}, ok: true},
+		{s: `\m`, para: parser.Paragraph{Style: "m", Align: "left", Continued: true}, ok: true},
+		{s: `\nb`, para: parser.Paragraph{Style: "nb", Align: "left", Continued: true}, ok: true},
+		{s: `\pi`, para: parser.Paragraph{Style: "pi", Indent: 1, Align: "left"}, ok: true},
+		{s: `\pi3`, para: parser.Paragraph{Style: "pi", Indent: 3, Align: "left"}, ok: true},
+		{s: `\mi`, para: parser.Paragraph{Style: "mi", Indent: 1, Align: "left", Continued: true}, ok: true},
+		{s: `\ph2`, para: parser.Paragraph{Style: "ph", Indent: 2, Align: "left", Hanging: true}, ok: true},
+		{s: `\pm`, para: parser.Paragraph{Style: "pm", Align: "left", Embedded: true}, ok: true},
+		{s: `\pmo`, para: parser.Paragraph{Style: "pmo", Align: "left", Embedded: true}, ok: true},
+		{s: `\pmc`, para: parser.Paragraph{Style: "pmc", Align: "center", Embedded: true}, ok: true},
+		{s: `\pmr`, para: parser.Paragraph{Style: "pmr", Align: "right", Embedded: true}, ok: true},
+		{s: `\pc`, para: parser.Paragraph{Style: "pc", Align: "center"}, ok: true},
+		{s: `\pr`, para: parser.Paragraph{Style: "pr", Align: "right"}, ok: true},
+		{s: `\cls`, para: parser.Paragraph{Style: "cls", Align: "left"}, ok: true},
+		{s: `\lit`, para: parser.Paragraph{Style: "lit", Align: "left"}, ok: true},
+		{s: `\pb`, para: parser.Paragraph{Style: "pb", Align: "left"}, ok: true},
+		{s: `\q1`},
+		{s: `\s1`},
+	}
+
+	for i, tt := range tests {
+		content, err := parser.NewParser(strings.NewReader(`\id MAT \c 1 ` + tt.s + ` \v 1 T1`)).Parse()
+		if err != nil {
+			t.Errorf("%d. %q: unexpected error: %v", i, tt.s, err)
+			continue
+		}
+		para, ok := content.Children[2].Paragraph()
+		if ok != tt.ok {
+			t.Errorf("%d. %q: ok mismatch: exp=%v got=%v", i, tt.s, tt.ok, ok)
+		} else if !reflect.DeepEqual(tt.para, para) {
+			t.Errorf("%d. %q: paragraph mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", i, tt.s, tt.para, para)
+		}
+	}
+}
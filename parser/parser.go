@@ -4,1249 +4,780 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strings"
 )
 
+// Options configures a Parser.
+type Options struct {
+	// Lenient makes the parser recover from errors and return a partial
+	// content instead of stopping at the first error
+	Lenient bool
+
+	// Stylesheet adds the markers of a Paratext stylesheet (such as the \z
+	// markers of a custom.sty) to the USFM 3 markers
+	Stylesheet *Stylesheet
+}
+
 // Parser represents a parser.
 type Parser struct {
-	s   *Scanner
-	buf struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
-		pos int    // scanner position (byte offset)
+	s           *Scanner
+	markers     *Registry
+	lenient     bool
+	diagnostics []Diagnostic
+	verse       *Content   // last verse marker, continued by paragraphs without a verse
+	open        []*Content // notes and character spans being read, innermost last
+	milestones  milestones // milestone starts waiting for their end
+	book        string     // book code from the \id marker
+	openIntro   *Content   // introduction continued by the next introduction markers
+	openList    *Content   // list continued by the next list items
+	lastHeading *Content   // section heading followed by its references
+	buf         struct {
+		tok  Token  // last read token
+		lit  string // last read literal
+		n    int    // buffer size (max=1)
+		span Span   // source span of the token
 	}
 }
 
 // NewParser returns a new instance of Parser.
 func NewParser(r io.Reader) *Parser {
-	return &Parser{s: NewScanner(r)}
+	return NewParserWithOptions(r, Options{})
+}
+
+// NewParserWithOptions returns a new instance of Parser using the options.
+func NewParserWithOptions(r io.Reader, o Options) *Parser {
+	s := NewScanner(r)
+	if o.Stylesheet != nil {
+		s.markers = o.Stylesheet.Registry()
+	}
+	return &Parser{s: s, markers: s.markers, lenient: o.Lenient, milestones: milestones{markers: s.markers}}
 }
 
 // Parse parses a USFM formatted book content
 func (p *Parser) Parse() (*Content, error) {
 	log.Printf("Scanning for book...")
 	book := &Content{}
-	book.Type = "book"
-	markerV := &Content{}
+	book.Type = BookNode.String()
 	for {
-		// Read a field.
-		tok, lit, pos := p.scanIgnoreWhitespace()
-		if tok == MarkerID {
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
-			book.Children = append(book.Children, marker)
-			tok, lit, pos = p.scanIgnoreWhitespace()
-			if tok == Text && len([]rune(lit)) == 3 {
-				child := &Content{}
-				child.Type = "bookcode"
-				child.Value = lit
-				child.Position = pos
-				book.Value = lit
-				book.Position = pos
-				marker.Children = append(marker.Children, child)
-				for {
-					tok, lit, pos = p.scanIgnoreWhitespace()
-					if !(tok == Text || tok == Number) {
-						p.unscan()
-						break
-					} else {
-						child := &Content{}
-						child.Type = "text"
-						child.Value = lit
-						child.Position = pos
-						marker.Children = append(marker.Children, child)
-					}
-				}
+		if err := p.parseNext(book); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	finishSpans(book)
+	if p.markers != defaultMarkers {
+		setMarkers(book, p.markers)
+	}
+	p.closeMilestones()
+
+	// A lenient parse returns the partial content with every error found
+	if err := p.err(); err != nil {
+		return book, err
+	}
+
+	// Return the successfully parsed statement.
+	return book, nil
+}
+
+// parseNext parses the next top level marker into the children of book.
+// It returns io.EOF at the end of the source.
+func (p *Parser) parseNext(book *Content) error {
+	n := len(book.Children)
+	err := p.parseBlock(book)
+	if len(book.Children) > n {
+		// A new block ends the introduction, list or heading being read
+		last := book.Children[len(book.Children)-1]
+		if p.openIntro != last {
+			p.openIntro = nil
+		}
+		if p.openList != last {
+			p.openList = nil
+		}
+		if p.lastHeading != last {
+			p.lastHeading = nil
+		}
+	}
+	return err
+}
+
+// isOpen reports whether the next markers may still add children to the
+// top level node.
+func (p *Parser) isOpen(node *Content) bool {
+	return node == p.openIntro || node == p.openList || node == p.lastHeading
+}
+
+// parseBlock parses the next top level marker for parseNext.
+func (p *Parser) parseBlock(book *Content) error {
+	// Read a field.
+	tok, lit, span := p.scanIgnoreWhitespace()
+	if tok == EOF {
+		return io.EOF
+	} else if tok == MarkerID {
+		marker := newMarker(lit, span)
+		book.Children = append(book.Children, marker)
+		tok, lit, span = p.scanIgnoreWhitespace()
+		if tok == Text && len([]rune(lit)) == 3 {
+			child := newNode(BookCodeNode, lit, span)
+			book.Value = lit
+			book.Position = span.Start.Offset
+			p.book = strings.ToUpper(lit)
+			p.verse = nil
+			marker.Children = append(marker.Children, child)
+			p.parseText(marker, TextNode)
+		} else {
+			if err := p.fail(Diagnostic{Code: CodeExpectedBookCode, Message: fmt.Sprintf("found %q, expected book code", lit), Span: span, Expected: []Token{Text}, Found: tok, Literal: lit}); err != nil {
+				return err
+			}
+			p.unscan()
+		}
+	} else if tok == MarkerIde {
+		marker := newMarker(lit, span)
+		book.Children = append(book.Children, marker)
+		p.parseText(marker, TextNode)
+	} else if tok == MarkerC {
+		marker := newMarker(lit, span)
+		book.Children = append(book.Children, marker)
+		tok, lit, span = p.scanIgnoreWhitespace()
+		if tok == Number {
+			p.verse = nil
+			child := newNode(ChapterNumberNode, lit, span)
+			marker.Children = append(marker.Children, child)
+			p.parseChapterNumbers(marker)
+		} else {
+			if err := p.fail(Diagnostic{Code: CodeExpectedChapterNumber, Message: fmt.Sprintf("found %q, expected chapter number", lit), Span: span, Expected: []Token{Number}, Found: tok, Literal: lit}); err != nil {
+				return err
+			}
+			// Drop the chapter and carry on from the next chapter or verse
+			book.Children = book.Children[:len(book.Children)-1]
+			p.unscan()
+			p.skipToVerse()
+		}
+	} else if tok == MarkerH {
+		log.Print("Found Heading marker.")
+		marker := newMarker(lit, span)
+		book.Children = append(book.Children, marker)
+		p.parseText(marker, HeadingNode)
+	} else if tok == MarkerD {
+		log.Print("Found Descriptive Title marker.")
+		marker := newMarker(lit, span)
+		book.Children = append(book.Children, marker)
+		p.parseText(marker, DescriptionNode)
+	} else if tok == MarkerP {
+		log.Print("Found Paragraph marker.")
+		markerP := newMarker(lit, span)
+		book.Children = append(book.Children, markerP)
+		if err := p.parseParagraph(markerP); err != nil {
+			return err
+		}
+	} else if tok == MarkerV {
+		log.Print("Creating fake Paragraph marker.")
+		markerP := newMarker("\\p", Span{Start: span.Start, End: span.Start})
+		book.Children = append(book.Children, markerP)
+		p.unscan()
+		if err := p.parseParagraph(markerP); err != nil {
+			return err
+		}
+	} else if isUnknownMarker(tok, lit) {
+		p.parseUnknown(book, lit, span)
+	} else if name, end := splitMarker(lit); tok != Illegal && !end && strings.HasPrefix(lit, `\`) {
+		// Any other registered marker is handled by its kind
+		def, _ := p.markers.Lookup(name)
+		if def == nil {
+			// A token the registry can't describe is kept like an unknown marker
+			p.parseUnknown(book, lit, span)
+		} else if isIntroduction(def) {
+			intro := p.introduction(book, span)
+			intro.Children = append(intro.Children, newMarker(lit, span))
+			p.parseInline(intro.Children[len(intro.Children)-1])
+			if def.Name == "ie" {
+				p.openIntro = nil
+			}
+		} else if isSectionReference(def.Name) {
+			marker := newMarker(lit, span)
+			if heading := p.lastHeading; heading != nil {
+				heading.Children = append(heading.Children, marker)
 			} else {
-				return nil, fmt.Errorf("found %q, expected book code", lit)
+				book.Children = append(book.Children, marker)
 			}
-		} else if tok == MarkerIde {
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
+			p.parseInline(marker)
+			p.addReference(marker)
+		} else if isHeading(def.Name) {
+			marker := newMarker(lit, span)
 			book.Children = append(book.Children, marker)
-			for {
-				tok, lit, pos = p.scanIgnoreWhitespace()
-				if !(tok == Text || tok == Number) {
-					p.unscan()
-					break
-				} else {
-					child := &Content{}
-					child.Type = "text"
-					child.Value = lit
-					child.Position = pos
-					marker.Children = append(marker.Children, child)
-				}
+			p.lastHeading = marker
+			p.parseInline(marker)
+		} else if def.Name == "esb" {
+			sidebar := newNode(SidebarNode, "", span)
+			book.Children = append(book.Children, sidebar)
+			if err := p.parseSidebar(sidebar); err != nil {
+				return err
+			}
+		} else if def.Name == "periph" {
+			periph := newNode(PeripheralNode, "", span)
+			book.Children = append(book.Children, periph)
+			divider := newMarker(lit, span)
+			periph.Children = append(periph.Children, divider)
+			if err := p.parsePeripheral(periph, divider); err != nil {
+				return err
 			}
-		} else if tok == MarkerC {
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
+		} else if def.Name == "esbe" {
+			p.warn(Diagnostic{Code: CodeUnmatchedMarker, Message: fmt.Sprintf("%s has no matching start", lit), Span: span, Found: tok, Literal: lit})
+		} else if def.Name == "tr" {
+			table := newNode(TableNode, "", Span{Start: span.Start, End: span.Start})
+			book.Children = append(book.Children, table)
+			p.unscan()
+			if err := p.parseTable(table); err != nil {
+				return err
+			}
+		} else if def.Kind == MilestoneKind {
+			marker := newMarker(lit, span)
 			book.Children = append(book.Children, marker)
-			tok, lit, pos = p.scanIgnoreWhitespace()
-			if tok == Number {
-				child := &Content{}
-				child.Type = "chapternumber"
-				child.Value = lit
-				child.Position = pos
-				marker.Children = append(marker.Children, child)
-			} else {
-				return nil, fmt.Errorf("found %q, expected chapter number", lit)
+			p.parseMilestone(marker)
+		} else if isListItem(def.Name) {
+			list := p.list(book, span)
+			marker := newMarker(lit, span)
+			list.Children = append(list.Children, marker)
+			if err := p.parseParagraph(marker); err != nil {
+				return err
 			}
-		} else if tok == MarkerH {
-			log.Print("Found Heading marker.")
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
+		} else if def.Kind == ParagraphKind && def.TextType == TextVerse {
+			markerP := newMarker(lit, span)
+			book.Children = append(book.Children, markerP)
+			if err := p.parseParagraph(markerP); err != nil {
+				return err
+			}
+		} else if def.Kind == HeaderKind || def.Kind == ParagraphKind {
+			marker := newMarker(lit, span)
 			book.Children = append(book.Children, marker)
-			for {
-				tok, lit, pos = p.scanIgnoreWhitespace()
-				if !(tok == Text || tok == Number) {
-					p.unscan()
-					break
-				} else {
-					child := &Content{}
-					child.Type = "heading"
-					child.Value = lit
-					child.Position = pos
-					marker.Children = append(marker.Children, child)
-				}
+			if _, ok := marker.headingIn(p.markers); ok {
+				p.lastHeading = marker
 			}
+			p.parseInline(marker)
+		}
+	}
+	return nil
+}
+
+// err returns a ParseError with the error diagnostics found, if any.
+func (p *Parser) err() error {
+	var errs []Diagnostic
+	for _, d := range p.diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	if len(errs) > 0 {
+		return &ParseError{Diagnostics: errs}
+	}
+	return nil
+}
+
+// parseChapterNumbers reads the alternate (\ca) and published (\cp) chapter
+// numbers following a chapter number into children of the chapter.
+func (p *Parser) parseChapterNumbers(markerC *Content) {
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		if name, end := splitMarker(lit); tok == Illegal || end || !strings.HasPrefix(lit, `\`) || !(strings.EqualFold(name, "ca") || strings.EqualFold(name, "cp")) {
+			p.unscan()
+			return
+		} else if strings.EqualFold(name, "ca") {
+			child := newMarker(lit, span)
+			markerC.Children = append(markerC.Children, child)
+			p.parseSpan(child)
+		} else {
+			child := newMarker(lit, span)
+			markerC.Children = append(markerC.Children, child)
+			p.parseText(child, TextNode)
+		}
+	}
+}
+
+// parseText reads the text following a marker into children of the given kind.
+func (p *Parser) parseText(marker *Content, kind NodeKind) {
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		if !(tok == Text || tok == Number) {
+			p.unscan()
+			return
+		}
+		marker.Children = append(marker.Children, newNode(kind, lit, span))
+	}
+}
+
+// parseInline reads the text, character markers and notes of a paragraph
+// until the next paragraph, chapter or verse.
+func (p *Parser) parseInline(marker *Content) {
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		kind := p.kind(tok, lit)
+		if tok == EOF || kind == ParagraphKind || kind == HeaderKind || kind == ChapterKind || kind == VerseKind {
+			p.unscan()
+			return
+		} else if kind == NoteKind {
+			child := newMarker(lit, span)
+			marker.Children = append(marker.Children, child)
+			p.parseNote(child)
+			p.addReferences(child)
+		} else if kind == CharacterKind {
+			child := p.parseCharacter(marker, lit, span)
+			if child != nil && child.IsMarker("ior") {
+				p.addReference(child)
+			}
+		} else if kind == MilestoneKind {
+			child := newMarker(lit, span)
+			marker.Children = append(marker.Children, child)
+			p.parseMilestone(child)
+		} else if isUnknownMarker(tok, lit) {
+			p.parseUnknown(marker, lit, span)
+		} else {
+			child := newNode(TextNode, lit, span)
+			marker.Children = append(marker.Children, child)
+		}
+	}
+}
+
+// parseParagraph reads the verses of a paragraph until the next paragraph,
+// chapter or header marker.
+func (p *Parser) parseParagraph(markerP *Content) error {
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		kind := p.kind(tok, lit)
+		if p.endsParagraph(tok, kind) {
+			p.unscan()
+			return nil
 		} else if tok == MarkerD {
 			log.Print("Found Descriptive Title marker.")
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
-			book.Children = append(book.Children, marker)
-			for {
-				tok, lit, pos = p.scanIgnoreWhitespace()
-				if !(tok == Text) {
-					p.unscan()
+			marker := newMarker(lit, span)
+			markerP.Children = append(markerP.Children, marker)
+			p.parseText(marker, DescriptionNode)
+		} else if tok == MarkerSP {
+			log.Print("Found Speaker Identification marker.")
+			marker := newMarker(lit, span)
+			markerP.Children = append(markerP.Children, marker)
+			p.parseText(marker, SpeakerNode)
+		} else if tok == MarkerB {
+			markerP.Children = append(markerP.Children, newMarker(lit, span))
+		} else if tok == MarkerV {
+			log.Print("Found Verse marker.")
+			markerV := newMarker(lit, span)
+			if ok, err := p.parseVerseNumber(markerV); err != nil {
+				return err
+			} else if !ok {
+				// Drop the verse and carry on from the next chapter or verse
+				continue
+			}
+			markerP.Children = append(markerP.Children, markerV)
+			p.parseVerse(markerV)
+		} else if tok == Text || tok == Number || kind == CharacterKind || kind == NoteKind || kind == MilestoneKind || isUnknownMarker(tok, lit) {
+			// OK we've found a paragraph (or poetry line)
+			// that continues a previous verse
+			p.unscan()
+			if p.verse == nil {
+				p.parseVerse(markerP)
+				continue
+			}
+			var verseNum *Content
+			for _, c := range p.verse.Children {
+				if c.Kind() == VerseNumberNode {
+					verseNum = c
 					break
-				} else {
-					child := &Content{}
-					child.Type = "description"
-					child.Value = lit
-					child.Position = pos
-					marker.Children = append(marker.Children, child)
 				}
 			}
-		} else if tok == MarkerP {
-			haveQ1Carryover := false
-			q1Carryover := &Content{}
-			log.Print("\n\n\n\nFound Paragraph marker.")
-			markerP := &Content{}
-			markerP.Type = "marker"
-			markerP.Value = lit
-			markerP.Position = pos
-			book.Children = append(book.Children, markerP)
-			for {
-				tok, lit, pos = p.scanIgnoreWhitespace()
-				/*if tok == MarkerP || tok == MarkerC {*/
-				if tok == EOF || tok == MarkerC || tok == MarkerP || tok == MarkerS {
-					p.unscan()
-					break
-				} else if tok == MarkerQ1 {
-					log.Print("Found Q1 marker.")
-					child := &Content{}
-					child.Type = "marker"
-					child.Value = lit
-					child.Position = pos
-					tok, lit, pos = p.scanIgnoreWhitespace()
-					if tok == MarkerV {
-						q1Carryover = child
-						haveQ1Carryover = true
-						p.unscan()
-						continue
-					}
-				} else if tok == MarkerD {
-					log.Print("Found Descriptive Title marker.")
-					marker := &Content{}
-					marker.Type = "marker"
-					marker.Value = lit
-					marker.Position = pos
-					markerP.Children = append(markerP.Children, marker)
-					for {
-						tok, lit, pos = p.scanIgnoreWhitespace()
-						if !(tok == Text) {
-							p.unscan()
-							break
-						} else {
-							child := &Content{}
-							child.Type = "description"
-							child.Value = lit
-							child.Position = pos
-							marker.Children = append(marker.Children, child)
-						}
-					}
-				} else if tok == MarkerV {
-					log.Print("\n\nFound Verse marker.")
-					markerV = &Content{}
-					markerV.Type = "marker"
-					markerV.Value = lit
-					markerV.Position = pos
-					markerP.Children = append(markerP.Children, markerV)
-					tok, lit, pos = p.scanIgnoreWhitespace()
-					if tok == Number {
-						child := &Content{}
-						child.Type = "versenumber"
-						child.Value = lit
-						child.Position = pos
-						markerV.Children = append(markerV.Children, child)
-						log.Printf("Verse Number is %v", child.Value)
+			at := Span{Start: p.buf.span.Start, End: p.buf.span.Start}
+			newVerseNum := newNode(VerseNumberNode, verseNum.Value, at)
+			newVerseNum.Children = verseNum.Children
+			markerPV := newMarker("\\v", at)
+			markerPV.Children = append(markerPV.Children, newVerseNum)
+			// Add a new "sub-verse" marker
+			markerSV := newNode(SubverseNode, "Sub-verse paragraph", at)
+			markerPV.Children = append(markerPV.Children, markerSV)
+			p.parseVerse(markerPV)
+			markerP.Children = append(markerP.Children, markerPV)
+		}
+	}
+}
+
+// parseVerseNumber reads the number of a verse marker. It returns false if
+// the number is missing and the parser skipped to the next chapter or verse.
+func (p *Parser) parseVerseNumber(markerV *Content) (bool, error) {
+	tok, lit, span := p.scanIgnoreWhitespace()
+	if tok != Number && !(tok == Text && isVerseNumber(lit)) {
+		if err := p.fail(Diagnostic{Code: CodeExpectedVerseNumber, Message: fmt.Sprintf("found %q, expected verse number", lit), Span: span, Expected: []Token{Number}, Found: tok, Literal: lit}); err != nil {
+			return false, err
+		}
+		p.unscan()
+		p.skipToVerse()
+		return false, nil
+	}
+	child := newNode(VerseNumberNode, lit, span)
+	markerV.Children = append(markerV.Children, child)
+	log.Printf("Verse Number is %v", child.Value)
+	p.verse = markerV
+	return true, nil
+}
 
-						// Add the q1Carryover if there is one
-						if haveQ1Carryover {
-							markerV.Children = append(markerV.Children, q1Carryover)
-							haveQ1Carryover = false
-							q1Carryover = &Content{}
-						}
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							//log.Printf("Token: %v Lit: %v", tok, lit)
-							if tok == 0x0085 || tok == EOF || tok == MarkerV || tok == MarkerC || tok == MarkerP || tok == MarkerS || tok == MarkerD {
-								p.unscan()
-								break
-							} else if tok == MarkerQ1 {
-								log.Print("Found Q1 marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == MarkerV {
-									q1Carryover = childA
-									haveQ1Carryover = true
-									p.unscan()
-									break
-								} else {
-									p.unscan()
-									markerV.Children = append(markerV.Children, childA)
-								}
-							} else if tok == MarkerQ2 {
-								log.Print("Found Q2 marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-							} else if tok == MarkerWJ {
-								log.Print("Found Jesus' Words markerV.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerWJ {
-										log.Print("Found Jesus' Words end marker.\n\n")
-										//p.unscan()
-										break
-									} else {
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childA.Children = append(childA.Children, childT)
-									}
-								}
-							} else if tok == MarkerAdd {
-								log.Print("Found Add marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerAdd {
-										log.Print("Found Add end marker.\n\n")
-										//p.unscan()
-										break
-									} else {
-										log.Print("Found Add subject text.")
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childA.Children = append(childA.Children, childT)
-									}
-								}
-							} else if tok == MarkerW {
-								log.Print("Found Wordlist marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerW {
-										log.Print("Found Wordlist end marker.\n\n")
-										break
-									} else if tok == Citation {
-										log.Print("Found Citation metadata.")
-										childC := &Content{}
-										childC.Type = "citation"
-										childC.Value = lit
-										childC.Position = pos
-										childW.Children = append(childW.Children, childC)
-									} else {
-										log.Print("Found Citation subject text.")
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childW.Children = append(childW.Children, childT)
-									}
+// parseVerse reads the content of a verse until the next verse, paragraph or
+// poetry line.
+func (p *Parser) parseVerse(markerV *Content) {
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		kind := p.kind(tok, lit)
+		if tok == MarkerV || p.endsVerse(tok, kind) {
+			p.unscan()
+			return
+		} else if tok == MarkerSP {
+			log.Print("Found Speaker Identification marker.")
+			child := newMarker(lit, span)
+			markerV.Children = append(markerV.Children, child)
+			p.parseText(child, SpeakerNode)
+		} else if kind == NoteKind {
+			child := newMarker(lit, span)
+			markerV.Children = append(markerV.Children, child)
+			p.parseNote(child)
+			p.addReferences(child)
+		} else if kind == CharacterKind {
+			p.parseCharacter(markerV, lit, span)
+		} else if kind == MilestoneKind {
+			child := newMarker(lit, span)
+			markerV.Children = append(markerV.Children, child)
+			p.parseMilestone(child)
+		} else if tok == MarkerB {
+			// Stanza breaks are empty
+			markerV.Children = append(markerV.Children, newMarker(lit, span))
+		} else if isUnknownMarker(tok, lit) {
+			p.parseUnknown(markerV, lit, span)
+		} else {
+			child := newNode(TextNode, lit, span)
+			markerV.Children = append(markerV.Children, child)
+		}
+	}
+}
 
-								}
-							} else if tok == MarkerSP {
-								log.Print("Found Speaker Identification marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok != Text {
-										p.unscan()
-										break
-									} else {
-										childT := &Content{}
-										childT.Type = "speaker"
-										childT.Value = lit
-										childT.Position = pos
-										childW.Children = append(childW.Children, childT)
-									}
-								}
-							} else if tok == MarkerQS {
-								log.Print("Found Selah marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerQS {
-										break
-									} else {
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childW.Children = append(childW.Children, childT)
-									}
-								}
-							} else if tok == MarkerF {
-								log.Print("Found Footnote marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerF {
-										log.Print("Found Footnote end marker.\n\n")
-										//p.unscan()
-										break
-									}
-								}
-							} else if tok == MarkerX {
-								log.Print("Found Cross-Reference marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerX {
-										log.Print("Found Cross-Reference end marker.\n\n")
-										//p.unscan()
-										break
-									}
-								}
-							} else if tok == MarkerB {
-							} else {
-								child := &Content{}
-								child.Type = "text"
-								child.Value = lit
-								child.Position = pos
-								markerV.Children = append(markerV.Children, child)
-							}
-						}
+// parseCharacter reads a character marker found in the content of parent.
+// It returns the span added to parent, or nil for a closing marker without
+// an open span, which is reported and dropped.
+func (p *Parser) parseCharacter(parent *Content, lit string, span Span) *Content {
+	if _, end := splitMarker(lit); end {
+		p.warn(Diagnostic{Code: CodeUnmatchedMarker, Message: fmt.Sprintf("%s has no matching start", lit), Span: span, Found: p.buf.tok, Literal: lit})
+		return nil
+	}
+	child := newMarker(lit, span)
+	parent.Children = append(parent.Children, child)
+	p.parseSpan(child)
+	return child
+}
 
-					} else {
-						return nil, fmt.Errorf("found %q, expected verse number", lit)
-					}
-				} else if tok == Text {
-					// OK we've found a paragraph that
-					// continues a previous verse
-					log.Print("\n\n\nWe're in a Paragraph with Text now:\n\n")
+// parseSpan reads the content of a character marker until its closing marker
+// and reports whether the span was closed. Character markers nested with a
+// plus (\wj ... \+nd Lord\+nd* ...) are read into the span, any other
+// character marker implicitly closes it.
+func (p *Parser) parseSpan(marker *Content) bool {
+	p.open = append(p.open, marker)
+	defer func() { p.open = p.open[:len(p.open)-1] }()
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		kind := p.kind(tok, lit)
+		_, end := splitMarker(lit)
+		if tok == EOF {
+			p.unclosed(marker, span)
+			p.unscan()
+			return false
+		} else if p.closes(marker, tok, lit) || (tok == EndMilestone && marker.Kind() == UnknownMarkerNode) {
+			marker.Span.End = span.End
+			return true
+		} else if kind == ParagraphKind || kind == ChapterKind || kind == VerseKind || kind == HeaderKind || (end && p.closesOpen(tok, lit)) {
+			// The span was never closed
+			p.unclosed(marker, span)
+			p.unscan()
+			return false
+		} else if kind == CharacterKind && !end && !isNested(lit) && marker.Kind() != UnknownMarkerNode {
+			// USFM 3 implicit closing: the marker starts a span of its own
+			p.unscan()
+			return true
+		} else if tok == Citation {
+			p.parseAttributes(marker, lit, span)
+		} else if kind == MilestoneKind {
+			child := newMarker(lit, span)
+			marker.Children = append(marker.Children, child)
+			p.parseMilestone(child)
+		} else if kind == NoteKind && !end {
+			child := newMarker(lit, span)
+			marker.Children = append(marker.Children, child)
+			p.parseNote(child)
+			p.addReferences(child)
+		} else if kind == CharacterKind {
+			p.parseCharacter(marker, lit, span)
+		} else if isUnknownMarker(tok, lit) {
+			p.parseUnknown(marker, lit, span)
+		} else {
+			child := newNode(TextNode, lit, span)
+			marker.Children = append(marker.Children, child)
+		}
+	}
+}
 
-					p.unscan()
-					var verseNum *Content
-					for _, c := range markerV.Children {
-						if c.Type == "versenumber" {
-							verseNum = c
-							break
-						}
-					}
-					newVerseNum := &Content{Type: "versenumber", Value: verseNum.Value, Children: verseNum.Children}
-					markerPV := &Content{}
-					markerPV.Type = "marker"
-					markerPV.Value = "\\v"
-					markerPV.Children = append(markerPV.Children, newVerseNum)
-					// Add a new "sub-verse" marker
-					markerSV := &Content{Type: "subverse", Value: "Sub-verse paragraph", Children: nil}
-					markerPV.Children = append(markerPV.Children, markerSV)
-					for {
-						tok, lit, pos = p.scanIgnoreWhitespace()
-						//log.Printf("Token: %v Lit: %v", tok, lit)
-						if tok == EOF || tok == MarkerV || tok == MarkerC || tok == MarkerP || tok == MarkerS {
-							log.Printf("We're breaking because we hit %v:%v", tok, lit)
-							p.unscan()
-							break
-						} else if tok == MarkerB {
-						} else if tok == MarkerD {
-							p.unscan()
-							break
-							log.Print("Found Descriptive Title marker.")
-							marker := &Content{}
-							marker.Type = "marker"
-							marker.Value = lit
-							marker.Position = pos
-							markerPV.Children = append(markerPV.Children, marker)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if !(tok == Text) {
-									p.unscan()
-									break
-								} else {
-									child := &Content{}
-									child.Type = "description"
-									child.Value = lit
-									child.Position = pos
-									marker.Children = append(marker.Children, child)
-								}
-							}
-						} else if tok == MarkerSP {
-							log.Print("Found Speaker Identification marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok != Text {
-									p.unscan()
-									break
-								} else {
-									childT := &Content{}
-									childT.Type = "speaker"
-									childT.Value = lit
-									childT.Position = pos
-									childA.Children = append(childA.Children, childT)
-								}
-							}
-						} else if tok == MarkerQ1 {
-							log.Print("Found Q1 marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-						} else if tok == MarkerQ2 {
-							log.Print("Found Q2 marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-						} else if tok == MarkerWJ {
-							log.Print("Found Jesus' Words marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerWJ {
-									log.Print("Found Jesus' Words end markerPV.\n\n")
-									//p.unscan()
-									break
-								} else {
-									childT := &Content{}
-									childT.Type = "text"
-									childT.Value = lit
-									childT.Position = pos
-									childA.Children = append(childA.Children, childT)
-								}
-							}
-						} else if tok == MarkerAdd {
-							log.Print("Found Add marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerAdd {
-									log.Print("Found Add end marker.\n\n")
-									//p.unscan()
-									break
-								} else {
-									log.Print("Found Add subject text.")
-									childT := &Content{}
-									childT.Type = "text"
-									childT.Value = lit
-									childT.Position = pos
-									childA.Children = append(childA.Children, childT)
-								}
-							}
-						} else if tok == MarkerW {
-							log.Print("Found Wordlist marker.")
-							childW := &Content{}
-							childW.Type = "marker"
-							childW.Value = lit
-							childW.Position = pos
-							markerPV.Children = append(markerPV.Children, childW)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerW {
-									log.Print("Found Wordlist end marker.\n\n")
-									break
-								} else if tok == Citation {
-									log.Print("Found Citation metadata.")
-									childC := &Content{}
-									childC.Type = "citation"
-									childC.Value = lit
-									childC.Position = pos
-									childW.Children = append(childW.Children, childC)
-								} else {
-									log.Print("Found Citation subject text.")
-									childT := &Content{}
-									childT.Type = "text"
-									childT.Value = lit
-									childT.Position = pos
-									childW.Children = append(childW.Children, childT)
-								}
+// parseNote reads the caller and the parts of a footnote (\fr, \ft, \fq etc.)
+// until the closing marker.
+func (p *Parser) parseNote(note *Content) {
+	tok, lit, span := p.scanIgnoreWhitespace()
+	if tok == Text || tok == Number || (tok == Illegal && !strings.HasPrefix(lit, `\`)) {
+		child := newNode(CallerNode, lit, span)
+		note.Children = append(note.Children, child)
+	} else {
+		p.unscan()
+	}
 
-							}
-						} else if tok == MarkerF {
-							log.Print("Found Footnote marker.")
-							childW := &Content{}
-							childW.Type = "marker"
-							childW.Value = lit
-							childW.Position = pos
-							markerPV.Children = append(markerPV.Children, childW)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerF {
-									log.Print("Found Footnote end marker.\n\n")
-									//p.unscan()
-									break
-								}
-							}
-						} else if tok == MarkerX {
-							log.Print("Found Cross-Reference marker.")
-							childW := &Content{}
-							childW.Type = "marker"
-							childW.Value = lit
-							childW.Position = pos
-							markerPV.Children = append(markerPV.Children, childW)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerX {
-									log.Print("Found Cross-Reference end marker.\n\n")
-									//p.unscan()
-									break
-								}
-							}
-						} else {
-							childT := &Content{}
-							childT.Type = "text"
-							childT.Value = lit
-							childT.Position = pos
-							markerPV.Children = append(markerPV.Children, childT)
-						}
-					}
-					markerP.Children = append(markerP.Children, markerPV)
-					//break
-				}
-			}
-		} else if tok == MarkerV || tok == MarkerQ1 {
-			haveQ1Carryover := false
-			q1Carryover := &Content{}
-			log.Print("\n\n\n\nCreating fake Paragraph marker.")
-			markerP := &Content{}
-			markerP.Type = "marker"
-			markerP.Value = "\\p"
-			book.Children = append(book.Children, markerP)
+	p.open = append(p.open, note)
+	defer func() { p.open = p.open[:len(p.open)-1] }()
+	defer setCategory(note)
+	part := note
+	for {
+		tok, lit, span = p.scanIgnoreWhitespace()
+		kind := p.kind(tok, lit)
+		_, end := splitMarker(lit)
+		if tok == EOF {
+			p.unclosed(note, span)
 			p.unscan()
-			for {
-				tok, lit, pos = p.scanIgnoreWhitespace()
-				/*if tok == MarkerP || tok == MarkerC {*/
-				if tok == EOF || tok == MarkerC || tok == MarkerP || tok == MarkerS {
-					p.unscan()
-					break
-				} else if tok == MarkerD {
-					log.Print("Found Descriptive Title marker.")
-					marker := &Content{}
-					marker.Type = "marker"
-					marker.Value = lit
-					marker.Position = pos
-					markerP.Children = append(markerP.Children, marker)
-					for {
-						tok, lit, pos = p.scanIgnoreWhitespace()
-						if !(tok == Text) {
-							p.unscan()
-							break
-						} else {
-							child := &Content{}
-							child.Type = "description"
-							child.Value = lit
-							child.Position = pos
-							marker.Children = append(marker.Children, child)
-						}
-					}
-				} else if tok == MarkerSP {
-					log.Print("Found Speaker Identification marker.")
-					child := &Content{}
-					child.Type = "marker"
-					child.Value = lit
-					child.Position = pos
-					markerP.Children = append(markerP.Children, child)
-					for {
-						tok, lit, pos = p.scanIgnoreWhitespace()
-						if tok != Text {
-							p.unscan()
-							break
-						} else {
-							childT := &Content{}
-							childT.Type = "speaker"
-							childT.Value = lit
-							childT.Position = pos
-							child.Children = append(child.Children, childT)
-						}
-					}
-				} else if tok == MarkerQS {
-					log.Print("Found Selah marker.")
-					childW := &Content{}
-					childW.Type = "marker"
-					childW.Value = lit
-					childW.Position = pos
-					markerP.Children = append(markerP.Children, childW)
-					for {
-						tok, lit, pos = p.scanIgnoreWhitespace()
-						if tok == EndMarkerQS {
-							break
-						} else {
-							childT := &Content{}
-							childT.Type = "text"
-							childT.Value = lit
-							childT.Position = pos
-							childW.Children = append(childW.Children, childT)
-						}
-					}
-				} else if tok == MarkerQ1 {
-					log.Print("Found Q1 marker.")
-					child := &Content{}
-					child.Type = "marker"
-					child.Value = lit
-					child.Position = pos
-					tok, lit, pos = p.scanIgnoreWhitespace()
-					if tok == MarkerV {
-						q1Carryover = child
-						haveQ1Carryover = true
-						p.unscan()
-						continue
-					}
-				} else if tok == MarkerV {
-					log.Print("\n\nFound Verse marker.")
-					markerV = &Content{}
-					markerV.Type = "marker"
-					markerV.Value = lit
-					markerV.Position = pos
-					markerP.Children = append(markerP.Children, markerV)
-					tok, lit, pos = p.scanIgnoreWhitespace()
-					if tok == Number {
-						child := &Content{}
-						child.Type = "versenumber"
-						child.Value = lit
-						child.Position = pos
-						markerV.Children = append(markerV.Children, child)
-						log.Printf("Verse Number is %v", child.Value)
+			return
+		} else if p.closes(note, tok, lit) {
+			note.Span.End = span.End
+			return
+		} else if kind == ParagraphKind || kind == ChapterKind || kind == VerseKind || kind == HeaderKind || (end && p.closesOpen(tok, lit)) {
+			// The note was never closed
+			p.unclosed(note, span)
+			p.unscan()
+			return
+		} else if name, end := splitMarker(lit); kind == CharacterKind && p.isNotePart(name) {
+			if end {
+				// Closed parts such as \fv ...\fv* return to the note text
+				part = note
+				continue
+			}
+			part = newMarker(lit, span)
+			note.Children = append(note.Children, part)
+		} else if tok == Citation {
+			p.parseAttributes(part, lit, span)
+		} else if kind == CharacterKind {
+			p.parseCharacter(part, lit, span)
+		} else if isUnknownMarker(tok, lit) {
+			p.parseUnknown(part, lit, span)
+		} else {
+			child := newNode(TextNode, lit, span)
+			part.Children = append(part.Children, child)
+		}
+	}
+}
 
-						// Add the q1Carryover if there is one
-						if haveQ1Carryover {
-							markerV.Children = append(markerV.Children, q1Carryover)
-							haveQ1Carryover = false
-							q1Carryover = &Content{}
-						}
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							//log.Printf("Token: %v Lit: %v", tok, lit)
-							if tok == 0x0085 || tok == EOF || tok == MarkerV || tok == MarkerC || tok == MarkerP || tok == MarkerS || tok == MarkerD {
-								p.unscan()
-								break
-							} else if tok == MarkerQS {
-								log.Print("Found Selah marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerQS {
-										break
-									} else {
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childW.Children = append(childW.Children, childT)
-									}
-								}
-							} else if tok == MarkerSP {
-								log.Print("Found Speaker Identification marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok != Text {
-										p.unscan()
-										break
-									} else {
-										childT := &Content{}
-										childT.Type = "speaker"
-										childT.Value = lit
-										childT.Position = pos
-										childA.Children = append(childA.Children, childT)
-									}
-								}
-							} else if tok == MarkerQ1 {
-								log.Print("Found Q1 marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == MarkerV {
-									q1Carryover = childA
-									haveQ1Carryover = true
-									p.unscan()
-									break
-								} else {
-									p.unscan()
-									markerV.Children = append(markerV.Children, childA)
-								}
-							} else if tok == MarkerQ2 {
-								log.Print("Found Q2 marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-							} else if tok == MarkerWJ {
-								log.Print("Found Jesus' Words markerV.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerWJ {
-										log.Print("Found Jesus' Words end marker.\n\n")
-										//p.unscan()
-										break
-									} else {
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childA.Children = append(childA.Children, childT)
-									}
-								}
-							} else if tok == MarkerAdd {
-								log.Print("Found Add marker.")
-								childA := &Content{}
-								childA.Type = "marker"
-								childA.Value = lit
-								childA.Position = pos
-								markerV.Children = append(markerV.Children, childA)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerAdd {
-										log.Print("Found Add end marker.\n\n")
-										//p.unscan()
-										break
-									} else {
-										log.Print("Found Add subject text.")
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childA.Children = append(childA.Children, childT)
-									}
-								}
-							} else if tok == MarkerW {
-								log.Print("Found Wordlist marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerW {
-										log.Print("Found Wordlist end marker.\n\n")
-										break
-									} else if tok == Citation {
-										log.Print("Found Citation metadata.")
-										childC := &Content{}
-										childC.Type = "citation"
-										childC.Value = lit
-										childC.Position = pos
-										childW.Children = append(childW.Children, childC)
-									} else {
-										log.Print("Found Citation subject text.")
-										childT := &Content{}
-										childT.Type = "text"
-										childT.Value = lit
-										childT.Position = pos
-										childW.Children = append(childW.Children, childT)
-									}
+// parseAttributes keeps the attribute list of a character marker as a
+// "citation" child and parses it into the attributes of the marker. The USFM 2
+// form of a figure is converted to the USFM 3 attributes.
+func (p *Parser) parseAttributes(marker *Content, lit string, span Span) {
+	child := newNode(CitationNode, lit, span)
+	marker.Children = append(marker.Children, child)
 
-								}
-							} else if tok == MarkerF {
-								log.Print("Found Footnote marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerF {
-										log.Print("Found Footnote end marker.\n\n")
-										//p.unscan()
-										break
-									}
-								}
-							} else if tok == MarkerX {
-								log.Print("Found Cross-Reference marker.")
-								childW := &Content{}
-								childW.Type = "marker"
-								childW.Value = lit
-								childW.Position = pos
-								markerV.Children = append(markerV.Children, childW)
-								for {
-									tok, lit, pos = p.scanIgnoreWhitespace()
-									if tok == EndMarkerX {
-										log.Print("Found Cross-Reference end marker.\n\n")
-										//p.unscan()
-										break
-									}
-								}
-							} else {
-								child := &Content{}
-								child.Type = "text"
-								child.Value = lit
-								child.Position = pos
-								markerV.Children = append(markerV.Children, child)
-							}
-						}
+	var defaultAttribute string
+	name, _ := splitMarker(marker.Value)
+	if def, _ := p.markers.Lookup(name); def != nil {
+		if def.Name == "fig" && isFigure2(lit) {
+			p.parseFigure2(marker, child)
+			return
+		}
+		defaultAttribute = def.DefaultAttribute
+	}
+	marker.Attributes = ParseAttributes(lit, defaultAttribute)
+}
 
-					} else {
-						return nil, fmt.Errorf("found %q, expected verse number", lit)
-					}
-				} else if tok == Text {
-					// OK we've found a paragraph that
-					// continues a previous verse
-					log.Print("\n\n\nWe're in a Paragraph with Text now:\n\n")
+// isNotePart reports whether the marker name is a footnote or cross-reference
+// part such as \ft or \xt.
+func (p *Parser) isNotePart(name string) bool {
+	def, _ := p.markers.Lookup(name)
+	return def != nil && def.Kind == CharacterKind && def.TextType == TextNote
+}
 
-					p.unscan()
-					var verseNum *Content
-					for _, c := range markerV.Children {
-						if c.Type == "versenumber" {
-							verseNum = c
-							break
-						}
-					}
-					newVerseNum := &Content{Type: "versenumber", Value: verseNum.Value, Children: verseNum.Children}
-					markerPV := &Content{}
-					markerPV.Type = "marker"
-					markerPV.Value = "\\v"
-					markerPV.Children = append(markerPV.Children, newVerseNum)
-					// Add a new "sub-verse" marker
-					markerSV := &Content{Type: "subverse", Value: "Sub-verse paragraph", Children: nil}
-					markerPV.Children = append(markerPV.Children, markerSV)
-					for {
-						tok, lit, pos = p.scanIgnoreWhitespace()
-						//log.Printf("Token: %v Lit: %v", tok, lit)
-						if tok == EOF || tok == MarkerV || tok == MarkerC || tok == MarkerP || tok == MarkerS {
-							log.Printf("We're breaking because we hit %v:%v", tok, lit)
-							p.unscan()
-							break
-						} else if tok == MarkerD {
-							log.Print("Found Descriptive Title marker.")
-							marker := &Content{}
-							marker.Type = "marker"
-							marker.Value = lit
-							marker.Position = pos
-							markerP.Children = append(markerP.Children, marker)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if !(tok == Text) {
-									p.unscan()
-									break
-								} else {
-									child := &Content{}
-									child.Type = "description"
-									child.Value = lit
-									child.Position = pos
-									marker.Children = append(marker.Children, child)
-								}
-							}
-						} else if tok == MarkerQ1 {
-							log.Print("Found Q1 marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-						} else if tok == MarkerQ2 {
-							log.Print("Found Q2 marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-						} else if tok == MarkerWJ {
-							log.Print("Found Jesus' Words marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerWJ {
-									log.Print("Found Jesus' Words end markerPV.\n\n")
-									//p.unscan()
-									break
-								} else {
-									childT := &Content{}
-									childT.Type = "text"
-									childT.Value = lit
-									childT.Position = pos
-									childA.Children = append(childA.Children, childT)
-								}
-							}
-						} else if tok == MarkerAdd {
-							log.Print("Found Add marker.")
-							childA := &Content{}
-							childA.Type = "marker"
-							childA.Value = lit
-							childA.Position = pos
-							markerPV.Children = append(markerPV.Children, childA)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerAdd {
-									log.Print("Found Add end marker.\n\n")
-									//p.unscan()
-									break
-								} else {
-									log.Print("Found Add subject text.")
-									childT := &Content{}
-									childT.Type = "text"
-									childT.Value = lit
-									childT.Position = pos
-									childA.Children = append(childA.Children, childT)
-								}
-							}
-						} else if tok == MarkerW {
-							log.Print("Found Wordlist marker.")
-							childW := &Content{}
-							childW.Type = "marker"
-							childW.Value = lit
-							childW.Position = pos
-							markerPV.Children = append(markerPV.Children, childW)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerW {
-									log.Print("Found Wordlist end marker.\n\n")
-									break
-								} else if tok == Citation {
-									log.Print("Found Citation metadata.")
-									childC := &Content{}
-									childC.Type = "citation"
-									childC.Value = lit
-									childC.Position = pos
-									childW.Children = append(childW.Children, childC)
-								} else {
-									log.Print("Found Citation subject text.")
-									childT := &Content{}
-									childT.Type = "text"
-									childT.Value = lit
-									childT.Position = pos
-									childW.Children = append(childW.Children, childT)
-								}
+// addReferences adds the structured target references of the \xt parts of a
+// note as "reference" children of each part.
+func (p *Parser) addReferences(note *Content) {
+	for _, part := range note.Children {
+		if name, _ := splitMarker(part.Value); part.Kind() != MarkerNode || !strings.EqualFold(name, "xt") {
+			continue
+		}
+		p.addReference(part)
+	}
+}
 
-							}
-						} else if tok == MarkerF {
-							log.Print("Found Footnote marker.")
-							childW := &Content{}
-							childW.Type = "marker"
-							childW.Value = lit
-							childW.Position = pos
-							markerPV.Children = append(markerPV.Children, childW)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerF {
-									log.Print("Found Footnote end marker.\n\n")
-									//p.unscan()
-									break
-								}
-							}
-						} else if tok == MarkerX {
-							log.Print("Found Cross-Reference marker.")
-							childW := &Content{}
-							childW.Type = "marker"
-							childW.Value = lit
-							childW.Position = pos
-							markerPV.Children = append(markerPV.Children, childW)
-							for {
-								tok, lit, pos = p.scanIgnoreWhitespace()
-								if tok == EndMarkerX {
-									log.Print("Found Cross-Reference end marker.\n\n")
-									//p.unscan()
-									break
-								}
-							}
-						} else {
-							childT := &Content{}
-							childT.Type = "text"
-							childT.Value = lit
-							childT.Position = pos
-							markerPV.Children = append(markerPV.Children, childT)
-						}
-					}
-					markerP.Children = append(markerP.Children, markerPV)
-					//break
-				}
-			}
-		} else if tok == MarkerS {
-			log.Print("Found Section Heading marker.")
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
-			book.Children = append(book.Children, marker)
-			/*} else if tok == MarkerV {
-			log.Print("Found Verse marker.")
-			marker := &Content{}
-			marker.Type = "marker"
-			marker.Value = lit
-			marker.Position = pos
-			book.Children = append(book.Children, marker)
-			tok, lit, pos = p.scanIgnoreWhitespace()
-			if tok == Number {
-				child := &Content{}
-				child.Type = "versenumber"
-				child.Value = lit
-				child.Position = pos
-				marker.Children = append(marker.Children, child)
-				for {
-					tok, lit, pos = p.scanIgnoreWhitespace()
-					//if !(tok == Text || tok == Number || tok == MarkerW) {
-					//	log.Printf("Invalid child token: %v", tok)
-					//	p.unscan()
-					//	break
-					//} else if tok == MarkerW {
-					if tok == 0x0085 || tok == EOF || tok == MarkerV || tok == MarkerC || tok == MarkerP || tok == MarkerS {
-						p.unscan()
-						break
-					} else if tok == MarkerWJ {
-						log.Print("Found Jesus' Words marker.")
-						childA := &Content{}
-						childA.Type = "marker"
-						childA.Value = lit
-						childA.Position = pos
-						marker.Children = append(marker.Children, childA)
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							if tok == EndMarkerWJ {
-								log.Print("Found Jesus' Words end marker.\n\n")
-								//p.unscan()
-								break
-							} else {
-								childT := &Content{}
-								childT.Type = "text"
-								childT.Value = lit
-								childT.Position = pos
-								childA.Children = append(childA.Children, childT)
-							}
-						}
-					} else if tok == MarkerAdd {
-						log.Print("Found Add marker.")
-						childA := &Content{}
-						childA.Type = "marker"
-						childA.Value = lit
-						childA.Position = pos
-						marker.Children = append(marker.Children, childA)
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							if tok == EndMarkerAdd {
-								log.Print("Found Add end marker.\n\n")
-								//p.unscan()
-								break
-							} else {
-								log.Print("Found Add subject text.")
-								childT := &Content{}
-								childT.Type = "text"
-								childT.Value = lit
-								childT.Position = pos
-								childA.Children = append(childA.Children, childT)
-							}
-						}
-					} else if tok == MarkerW {
-						log.Print("Found Wordlist marker.")
-						markerEnd := false
-						childW := &Content{}
-						childW.Type = "marker"
-						childW.Value = lit
-						childW.Position = pos
-						marker.Children = append(marker.Children, childW)
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							if tok == EndMarkerW {
-								log.Print("Found Wordlist end marker.\n\n")
-								markerEnd = true
-								//p.unscan()
-								break
-							} else if tok == Citation {
-								log.Print("Found Citation metadata.")
-								childC := &Content{}
-								childC.Type = "citation"
-								childC.Value = lit
-								childC.Position = pos
-								childW.Children = append(childW.Children, childC)
-							} else {
-								log.Print("Found Citation subject text.")
-								childT := &Content{}
-								childT.Type = "text"
-								childT.Value = lit
-								childT.Position = pos
-								childW.Children = append(childW.Children, childT)
-							}
+// addReference appends the references in the text of a marker (e.g. \xt or
+// \ior) as "reference" children.
+func (p *Parser) addReference(marker *Content) {
+	for _, ref := range ParseReferences(textOf(marker), p.book) {
+		child := newNode(ReferenceNode, ref.OSIS(), marker.Span)
+		marker.Children = append(marker.Children, child)
+	}
+}
 
-						}
-						if markerEnd {
-							//break
-						}
-					} else if tok == MarkerF {
-						log.Print("Found Footnote marker.")
-						childW := &Content{}
-						childW.Type = "marker"
-						childW.Value = lit
-						childW.Position = pos
-						marker.Children = append(marker.Children, childW)
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							if tok == EndMarkerF {
-								log.Print("Found Footnote end marker.\n\n")
-								//p.unscan()
-								break
-							}
-						}
-					} else if tok == MarkerX {
-						log.Print("Found Cross-Reference marker.")
-						childW := &Content{}
-						childW.Type = "marker"
-						childW.Value = lit
-						childW.Position = pos
-						marker.Children = append(marker.Children, childW)
-						for {
-							tok, lit, pos = p.scanIgnoreWhitespace()
-							if tok == EndMarkerX {
-								log.Print("Found Cross-Reference end marker.\n\n")
-								//p.unscan()
-								break
-							}
-						}
-					} else {
-						child := &Content{}
-						child.Type = "text"
-						child.Value = lit
-						child.Position = pos
-						marker.Children = append(marker.Children, child)
-					}
-				}
+// closes reports whether the token is the closing marker of the given marker.
+func (p *Parser) closes(marker *Content, tok Token, lit string) bool {
+	name, end := splitMarker(lit)
+	if !end || (tok == Illegal && marker.Kind() != UnknownMarkerNode) {
+		return false
+	}
+	open, _ := splitMarker(marker.Value)
+	return strings.EqualFold(name, open)
+}
 
-			} else {
-				return nil, fmt.Errorf("found %q, expected verse number", lit)
-			}*/
-		} else if tok == EOF {
-			break
+// closesOpen reports whether the token is the closing marker of an open note
+// or character span.
+func (p *Parser) closesOpen(tok Token, lit string) bool {
+	for _, open := range p.open {
+		if p.closes(open, tok, lit) {
+			return true
 		}
 	}
-	// Return the successfully parsed statement.
-	return book, nil
+	return false
+}
+
+// kind returns the kind of marker a token represents.
+func (p *Parser) kind(tok Token, lit string) MarkerKind {
+	if tok == MarkerP {
+		// Covers the '¶' paragraph sign as well
+		return ParagraphKind
+	} else if tok == Text || tok == Number || tok == Whitespace || tok == EOF || tok == Citation || tok == Illegal {
+		return UnknownKind
+	}
+	return p.markers.Kind(lit)
+}
+
+// endsParagraph reports whether the token starts a new block outside the
+// current paragraph.
+func (p *Parser) endsParagraph(tok Token, kind MarkerKind) bool {
+	switch kind {
+	case HeaderKind, ChapterKind:
+		return true
+	case ParagraphKind:
+		return !(tok == MarkerB || tok == MarkerSP || tok == MarkerD)
+	}
+	return tok == EOF
+}
+
+// endsVerse reports whether the token ends the current verse.
+func (p *Parser) endsVerse(tok Token, kind MarkerKind) bool {
+	if tok == MarkerD {
+		return true
+	}
+	return p.endsParagraph(tok, kind)
+}
+
+// fail records an error diagnostic. It returns the error to stop parsing with,
+// or nil if the parser is lenient and should recover.
+func (p *Parser) fail(d Diagnostic) error {
+	d.Severity = SeverityError
+	p.diagnostics = append(p.diagnostics, d)
+	if p.lenient {
+		return nil
+	}
+	return &ParseError{Diagnostics: []Diagnostic{d}}
+}
+
+// warn records a warning diagnostic.
+func (p *Parser) warn(d Diagnostic) {
+	d.Severity = SeverityWarning
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// unclosed warns about a marker which is missing its closing marker.
+func (p *Parser) unclosed(marker *Content, span Span) {
+	name, _ := splitMarker(marker.Value)
+	if def, _ := p.markers.Lookup(name); def == nil || !def.Closed {
+		return
+	}
+	p.warn(Diagnostic{Code: CodeUnclosedMarker, Message: fmt.Sprintf("%s is not closed, expected %s*", marker.Value, marker.Value), Span: marker.Span, Found: p.buf.tok, Literal: p.buf.lit})
+}
+
+// skipToVerse skips ahead to the next chapter or verse marker.
+func (p *Parser) skipToVerse() {
+	for {
+		tok, _, _ := p.scanIgnoreWhitespace()
+		if tok == MarkerV || tok == MarkerC || tok == EOF {
+			p.unscan()
+			return
+		}
+	}
+}
+
+// Diagnostics returns the errors and warnings found by Parse.
+func (p *Parser) Diagnostics() []Diagnostic {
+	return p.diagnostics
+}
+
+// finishSpans extends the span of every node to cover its children. Nodes
+// without a source token of their own (such as a fake paragraph) start at
+// their first child.
+func finishSpans(c *Content) {
+	for _, child := range c.Children {
+		finishSpans(child)
+		if c.Span.Start.Line == 0 {
+			c.Span.Start = child.Span.Start
+		}
+		if child.Span.End.Offset > c.Span.End.Offset {
+			c.Span.End = child.Span.End
+		}
+	}
+}
+
+// textOf joins the text children of a node.
+func textOf(c *Content) string {
+	var words []string
+	for _, child := range c.Children {
+		if child.Kind() == TextNode {
+			words = append(words, child.Value)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// newMarker returns a marker node for the marker literal. The plus of a
+// nested character marker is dropped (`\+nd` gives `\nd`), the nesting is
+// kept by the tree.
+func newMarker(lit string, span Span) *Content {
+	if isNested(lit) {
+		lit = `\` + strings.TrimPrefix(lit, `\+`)
+	}
+	return newNode(MarkerNode, lit, span)
+}
+
+// newNode returns a node of the given kind for a scanned token.
+func newNode(kind NodeKind, value string, span Span) *Content {
+	node := &Content{}
+	node.Type = kind.String()
+	node.Value = value
+	node.Position = span.Start.Offset
+	node.Span = span
+	return node
 }
 
 // scan returns the next token from the underlying scanner.
 // If a token has been unscanned then read that instead.
-func (p *Parser) scan() (tok Token, lit string, pos int) {
+func (p *Parser) scan() (tok Token, lit string, span Span) {
 	// If we have a token on the buffer, then return it.
 	if p.buf.n != 0 {
 		p.buf.n = 0
-		return p.buf.tok, p.buf.lit, p.buf.pos
+		return p.buf.tok, p.buf.lit, p.buf.span
 	}
 
 	// Otherwise read the next token from the scanner.
-	tok, lit, pos = p.s.Scan()
+	tok, lit, span = p.s.ScanSpan()
 
 	// Save it to the buffer in case we unscan later.
-	p.buf.tok, p.buf.lit, p.buf.pos = tok, lit, pos
+	p.buf.tok, p.buf.lit, p.buf.span = tok, lit, span
 
 	return
 }
 
 // scanIgnoreWhitespace scans the next non-whitespace token.
-func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string, pos int) {
-	tok, lit, pos = p.scan()
-	if tok == Whitespace {
-		tok, lit, pos = p.scan()
-	}
-	return
-}
-
-// scanAlnumAndIgnoreWhitespace scans the next non-whitespace token.
-func (p *Parser) scanAlnumAndIgnoreWhitespace() (tok Token, lit string, pos int) {
-	tok, lit, pos = p.scan()
+func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string, span Span) {
+	tok, lit, span = p.scan()
 	if tok == Whitespace {
-		tok, lit, pos = p.scan()
+		tok, lit, span = p.scan()
 	}
 	return
 }
@@ -5,7 +5,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/baijum/usfm/parser"
+	"github.com/socceroos/usfm/parser"
 )
 
 // Ensure the parser can parse strings into Content ASTs.
@@ -77,11 +77,17 @@ func TestParser(t *testing.T) {
 				Children: []*parser.Content{
 					&parser.Content{
 						Type:  "marker",
-						Value: "\\v",
+						Value: "\\p",
 						Children: []*parser.Content{
-							&parser.Content{Type: "versenumber", Value: "1"},
-							&parser.Content{Type: "text", Value: "T1"},
-							&parser.Content{Type: "text", Value: "200"},
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1"},
+									&parser.Content{Type: "text", Value: "T1"},
+									&parser.Content{Type: "text", Value: "200"},
+								},
+							},
 						},
 					},
 				},
@@ -118,20 +124,239 @@ func TestParser(t *testing.T) {
 					},
 					&parser.Content{
 						Type:  "marker",
-						Value: "\\v",
+						Value: "\\p",
+						Children: []*parser.Content{
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1"},
+									&parser.Content{Type: "text", Value: "T3"},
+									&parser.Content{Type: "text", Value: "200"},
+								},
+							},
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "28"},
+									&parser.Content{Type: "text", Value: "T4"},
+									&parser.Content{Type: "text", Value: "T5"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			s: `\mt1 Ruth \p \v 1 T1 \nd Lord\nd* T2`,
+			content: &parser.Content{
+				Type:  "book",
+				Value: "",
+				Children: []*parser.Content{
+					&parser.Content{
+						Type:  "marker",
+						Value: "\\mt1",
+						Children: []*parser.Content{
+							&parser.Content{Type: "text", Value: "Ruth"},
+						},
+					},
+					&parser.Content{
+						Type:  "marker",
+						Value: "\\p",
+						Children: []*parser.Content{
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1"},
+									&parser.Content{Type: "text", Value: "T1"},
+									&parser.Content{
+										Type:  "marker",
+										Value: "\\nd",
+										Children: []*parser.Content{
+											&parser.Content{Type: "text", Value: "Lord"},
+										},
+									},
+									&parser.Content{Type: "text", Value: "T2"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			s: `\v 1 T1\f + \fr 1:1 \ft T2 \fq T3\f* T4`,
+			content: &parser.Content{
+				Type:  "book",
+				Value: "",
+				Children: []*parser.Content{
+					&parser.Content{
+						Type:  "marker",
+						Value: "\\p",
+						Children: []*parser.Content{
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1"},
+									&parser.Content{Type: "text", Value: "T1"},
+									&parser.Content{
+										Type:  "marker",
+										Value: "\\f",
+										Children: []*parser.Content{
+											&parser.Content{Type: "caller", Value: "+"},
+											&parser.Content{
+												Type:     "marker",
+												Value:    "\\fr",
+												Children: []*parser.Content{&parser.Content{Type: "text", Value: "1:1"}},
+											},
+											&parser.Content{
+												Type:     "marker",
+												Value:    "\\ft",
+												Children: []*parser.Content{&parser.Content{Type: "text", Value: "T2"}},
+											},
+											&parser.Content{
+												Type:     "marker",
+												Value:    "\\fq",
+												Children: []*parser.Content{&parser.Content{Type: "text", Value: "T3"}},
+											},
+										},
+									},
+									&parser.Content{Type: "text", Value: "T4"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			s: `\v 1 T1\x - \xo 1:1 \xt Jn 1:1\x*`,
+			content: &parser.Content{
+				Type:  "book",
+				Value: "",
+				Children: []*parser.Content{
+					&parser.Content{
+						Type:  "marker",
+						Value: "\\p",
+						Children: []*parser.Content{
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1"},
+									&parser.Content{Type: "text", Value: "T1"},
+									&parser.Content{
+										Type:  "marker",
+										Value: "\\x",
+										Children: []*parser.Content{
+											&parser.Content{Type: "caller", Value: "-"},
+											&parser.Content{
+												Type:     "marker",
+												Value:    "\\xo",
+												Children: []*parser.Content{&parser.Content{Type: "text", Value: "1:1"}},
+											},
+											&parser.Content{
+												Type:  "marker",
+												Value: "\\xt",
+												Children: []*parser.Content{
+													&parser.Content{Type: "text", Value: "Jn"},
+													&parser.Content{Type: "text", Value: "1:1"},
+													&parser.Content{Type: "reference", Value: "JHN.1.1"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			s: `\v 1 \w gracious|lemma="grace" strong="H2603"\w*`,
+			content: &parser.Content{
+				Type:  "book",
+				Value: "",
+				Children: []*parser.Content{
+					&parser.Content{
+						Type:  "marker",
+						Value: "\\p",
+						Children: []*parser.Content{
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1"},
+									&parser.Content{
+										Type:       "marker",
+										Value:      "\\w",
+										Attributes: map[string]string{"lemma": "grace", "strong": "H2603"},
+										Children: []*parser.Content{
+											&parser.Content{Type: "text", Value: "gracious"},
+											&parser.Content{Type: "citation", Value: `|lemma="grace" strong="H2603"`},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+
+		{
+			s: `\c 2 \ca 3\ca* \cp B \v 1-3 T1 \v 4a \vp 4\vp* T2`,
+			content: &parser.Content{
+				Type:  "book",
+				Value: "",
+				Children: []*parser.Content{
+					&parser.Content{
+						Type:  "marker",
+						Value: "\\c",
 						Children: []*parser.Content{
-							&parser.Content{Type: "versenumber", Value: "1"},
-							&parser.Content{Type: "text", Value: "T3"},
-							&parser.Content{Type: "text", Value: "200"},
+							&parser.Content{Type: "chapternumber", Value: "2"},
+							&parser.Content{
+								Type:     "marker",
+								Value:    "\\ca",
+								Children: []*parser.Content{&parser.Content{Type: "text", Value: "3"}},
+							},
+							&parser.Content{
+								Type:     "marker",
+								Value:    "\\cp",
+								Children: []*parser.Content{&parser.Content{Type: "text", Value: "B"}},
+							},
 						},
 					},
 					&parser.Content{
 						Type:  "marker",
-						Value: "\\v",
+						Value: "\\p",
 						Children: []*parser.Content{
-							&parser.Content{Type: "versenumber", Value: "28"},
-							&parser.Content{Type: "text", Value: "T4"},
-							&parser.Content{Type: "text", Value: "T5"},
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "1-3"},
+									&parser.Content{Type: "text", Value: "T1"},
+								},
+							},
+							&parser.Content{
+								Type:  "marker",
+								Value: "\\v",
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "4a"},
+									&parser.Content{
+										Type:     "marker",
+										Value:    "\\vp",
+										Children: []*parser.Content{&parser.Content{Type: "text", Value: "4"}},
+									},
+									&parser.Content{Type: "text", Value: "T2"},
+								},
+							},
 						},
 					},
 				},
@@ -141,10 +366,12 @@ func TestParser(t *testing.T) {
 		// Errors
 		{s: `\id X T1 200`, err: `found "X", expected book code`},
 		{s: `\v X T1 200`, err: `found "X", expected verse number`},
+		{s: `\v 3-1a T1`, err: `found "3-1a", expected verse number`},
 	}
 
 	for i, tt := range tests {
 		content, err := parser.NewParser(strings.NewReader(tt.s)).Parse()
+		clearPositions(content)
 		if !reflect.DeepEqual(tt.err, errstring(err)) {
 			t.Errorf("%d. %q: error mismatch:\n  exp=%s\n  got=%s\n\n", i, tt.s, tt.err, err)
 		} else if tt.err == "" && !reflect.DeepEqual(tt.content, content) {
@@ -153,6 +380,18 @@ func TestParser(t *testing.T) {
 	}
 }
 
+// clearPositions zeroes the byte positions and spans so contents can be compared by structure.
+func clearPositions(c *parser.Content) {
+	if c == nil {
+		return
+	}
+	c.Position = 0
+	c.Span = parser.Span{}
+	for _, child := range c.Children {
+		clearPositions(child)
+	}
+}
+
 // errstring returns the string representation of an error.
 func errstring(err error) string {
 	if err != nil {
@@ -160,3 +399,79 @@ func errstring(err error) string {
 	}
 	return ""
 }
+
+// Ensure nodes carry spans covering their children and closing markers.
+func TestParserSpans(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader("\\p\r\n\\v 1 Héllo \\wj ho\\wj*\r\n")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := content.Children[0]
+	v := p.Children[0]
+	wj := v.Children[2]
+
+	var tests = []struct {
+		name  string
+		span  parser.Span
+		start parser.Location
+		end   parser.Location
+	}{
+		{name: `\p`, span: p.Span, start: parser.Location{Offset: 0, Line: 1, Column: 1, Column16: 1}, end: parser.Location{Offset: 26, Line: 2, Column: 22, Column16: 22}},
+		{name: `\v`, span: v.Span, start: parser.Location{Offset: 4, Line: 2, Column: 1, Column16: 1}, end: parser.Location{Offset: 26, Line: 2, Column: 22, Column16: 22}},
+		{name: `\wj`, span: wj.Span, start: parser.Location{Offset: 16, Line: 2, Column: 12, Column16: 12}, end: parser.Location{Offset: 26, Line: 2, Column: 22, Column16: 22}},
+	}
+	for i, tt := range tests {
+		if tt.start != tt.span.Start || tt.end != tt.span.End {
+			t.Errorf("%d. %s span mismatch: exp=%+v-%+v got=%+v-%+v", i, tt.name, tt.start, tt.end, tt.span.Start, tt.span.End)
+		}
+	}
+	if wj.Position != 16 {
+		t.Errorf(`\wj position mismatch: exp=16 got=%d`, wj.Position)
+	}
+}
+
+// Ensure a lenient parse recovers from errors and reports every diagnostic.
+func TestParserLenient(t *testing.T) {
+	p := parser.NewParserWithOptions(strings.NewReader(`\id X \c 1 \p \v X T1 \v 2 T2 \wj T3 \c Y T4 \v 3 T5`), parser.Options{Lenient: true})
+	content, err := p.Parse()
+	if content == nil {
+		t.Fatalf("expected partial content, got nil")
+	}
+	perr, ok := err.(*parser.ParseError)
+	if !ok {
+		t.Fatalf("expected a *parser.ParseError, got %#v", err)
+	}
+
+	var codes []string
+	for _, d := range perr.Diagnostics {
+		codes = append(codes, d.Code)
+	}
+	expCodes := []string{parser.CodeExpectedBookCode, parser.CodeExpectedVerseNumber, parser.CodeExpectedChapterNumber}
+	if !reflect.DeepEqual(expCodes, codes) {
+		t.Errorf("error codes mismatch: exp=%v got=%v", expCodes, codes)
+	}
+	if exp := `found "X", expected book code (and 2 more errors)`; err.Error() != exp {
+		t.Errorf("error message mismatch: exp=%q got=%q", exp, err.Error())
+	}
+
+	all := p.Diagnostics()
+	if len(all) != 4 || all[2].Code != parser.CodeUnclosedMarker || all[2].Severity != parser.SeverityWarning {
+		t.Errorf("expected an unclosed marker warning, got %v", all)
+	}
+	if d := perr.Diagnostics[1]; d.Span.Start.Column != 18 || d.Found != parser.Text || d.Literal != "X" {
+		t.Errorf("verse number diagnostic mismatch: %v", d)
+	}
+
+	// Verses 2 and 3 survive the errors around them
+	var verses []string
+	for _, c := range content.Children {
+		for _, v := range c.Children {
+			if v.Value == `\v` {
+				verses = append(verses, v.Children[0].Value)
+			}
+		}
+	}
+	if !reflect.DeepEqual([]string{"2", "3"}, verses) {
+		t.Errorf("verse mismatch: exp=[2 3] got=%v", verses)
+	}
+}
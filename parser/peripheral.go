@@ -0,0 +1,49 @@
+package parser
+
+// Peripheral describes a division of a peripheral book (\periph).
+type Peripheral struct {
+	// Title is the title of the division (e.g. "Title Page")
+	Title string
+
+	// ID is the identifier of the division (e.g. "title")
+	ID string
+}
+
+// Peripheral returns the division described by a peripheral node. It returns
+// false if the node is not a peripheral division.
+func (c *Content) Peripheral() (Peripheral, bool) {
+	if c.Kind() != PeripheralNode || len(c.Children) == 0 || !c.Children[0].IsMarker("periph") {
+		return Peripheral{}, false
+	}
+	divider := c.Children[0]
+	return Peripheral{Title: textOf(divider), ID: divider.Attributes["id"]}, true
+}
+
+// parsePeripheral reads the title and attributes of a \periph divider and
+// the markers of the division up to the next divider, chapter or book into
+// the peripheral node. Paragraphs of a division do not continue a verse.
+func (p *Parser) parsePeripheral(periph *Content, divider *Content) error {
+	p.verse = nil
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		if tok == Text || tok == Number {
+			divider.Children = append(divider.Children, newNode(TextNode, lit, span))
+		} else if tok == Citation {
+			p.parseAttributes(divider, lit, span)
+		} else {
+			p.unscan()
+			break
+		}
+	}
+
+	for {
+		tok, lit, _ := p.scanIgnoreWhitespace()
+		p.unscan()
+		if name, _ := splitMarker(lit); tok == EOF || tok == MarkerID || tok == MarkerC || name == "periph" {
+			return nil
+		}
+		if err := p.parseNext(periph); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure \periph dividers split a peripheral book into divisions.
+func TestParserPeripheral(t *testing.T) {
+	s := `\id FRT Front matter
+\h Front Matter
+\periph Title Page|id="title"
+\mt1 The Holy Bible
+\periph Foreword|foreword
+\is Foreword
+\ip T1
+\p T2
+\periph Notes
+\p T3`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type division struct {
+		parser.Peripheral
+		Content string
+	}
+	var got []division
+	for _, c := range content.Children {
+		if periph, ok := c.Peripheral(); ok {
+			got = append(got, division{Peripheral: periph, Content: outline(c.Children[1:])})
+		}
+	}
+	exp := []division{
+		{Peripheral: parser.Peripheral{Title: "Title Page", ID: "title"}, Content: `mt1(The Holy Bible)`},
+		{Peripheral: parser.Peripheral{Title: "Foreword", ID: "foreword"}, Content: `introduction(is(Foreword) ip(T1)) p(T2)`},
+		{Peripheral: parser.Peripheral{Title: "Notes"}, Content: `p(T3)`},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("divisions mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+	if n := len(content.Children); n != 5 {
+		t.Errorf("expected 5 book children, got %d", n)
+	}
+}
+
+// Ensure peripheral book codes are recognized.
+func TestIsPeripheral(t *testing.T) {
+	for code, exp := range map[string]bool{"FRT": true, "glo": true, "XXA": true, "XXG": true, "MAT": false, "XXH": false} {
+		if got := parser.IsPeripheral(code); got != exp {
+			t.Errorf("%s: exp=%v got=%v", code, exp, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package parser
+
+// Line describes a poetry line marker such as \q2, \qr or \qm1.
+type Line struct {
+	// Style is the marker name without level: "q", "qr", "qc", "qa", "qm" or "qd"
+	Style string
+
+	// Indent is the indent level of \q# and \qm# lines (1 for \q and \q1),
+	// 0 for the other lines
+	Indent int
+
+	// Align is "left", "right" (\qr) or "center" (\qc)
+	Align string
+}
+
+// isPoetryLine reports whether the marker name (without level) is a poetry line.
+func isPoetryLine(name string) bool {
+	switch name {
+	case "q", "qr", "qc", "qa", "qm", "qd":
+		return true
+	}
+	return false
+}
+
+// Line returns the poetry line described by a line marker. It returns false
+// if the node is not a poetry line.
+func (c *Content) Line() (Line, bool) {
+	def, level := c.registry().Lookup(c.Marker())
+	if def == nil || !isPoetryLine(def.Name) {
+		return Line{}, false
+	}
+
+	line := Line{Style: def.Name, Align: "left"}
+	if def.Levels > 0 {
+		line.Indent = level
+		if level == 0 {
+			line.Indent = 1
+		}
+	}
+	if def.Name == "qr" {
+		line.Align = "right"
+	} else if def.Name == "qc" {
+		line.Align = "center"
+	}
+	return line, true
+}
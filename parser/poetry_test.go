@@ -0,0 +1,88 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure poetry lines are parsed as line nodes and verses spanning several
+// lines are continued in each line.
+func TestParserPoetry(t *testing.T) {
+	s := `\id PSA \c 119 \qa Aleph \q1 \v 1 Blessed are \q2 those \qac who\qac* walk \q3 T3 \q4 T4 \qr Selah \qc T5 \qm1 T6 \q1 \v 2 T7 \qd For the director`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type line struct {
+		Marker       string
+		Line         parser.Line
+		Verse        string
+		Continuation bool
+	}
+	var got []line
+	for _, c := range content.Children {
+		l, ok := c.Line()
+		if !ok {
+			continue
+		}
+		entry := line{Marker: c.Value, Line: l}
+		if len(c.Children) > 0 {
+			entry.Verse = c.Children[0].VerseNumber()
+			entry.Continuation = c.Children[0].IsContinuation()
+		}
+		got = append(got, entry)
+	}
+
+	exp := []line{
+		{Marker: `\qa`, Line: parser.Line{Style: "qa", Align: "left"}},
+		{Marker: `\q1`, Line: parser.Line{Style: "q", Indent: 1, Align: "left"}, Verse: "1"},
+		{Marker: `\q2`, Line: parser.Line{Style: "q", Indent: 2, Align: "left"}, Verse: "1", Continuation: true},
+		{Marker: `\q3`, Line: parser.Line{Style: "q", Indent: 3, Align: "left"}, Verse: "1", Continuation: true},
+		{Marker: `\q4`, Line: parser.Line{Style: "q", Indent: 4, Align: "left"}, Verse: "1", Continuation: true},
+		{Marker: `\qr`, Line: parser.Line{Style: "qr", Align: "right"}, Verse: "1", Continuation: true},
+		{Marker: `\qc`, Line: parser.Line{Style: "qc", Align: "center"}, Verse: "1", Continuation: true},
+		{Marker: `\qm1`, Line: parser.Line{Style: "qm", Indent: 1, Align: "left"}, Verse: "1", Continuation: true},
+		{Marker: `\q1`, Line: parser.Line{Style: "q", Indent: 1, Align: "left"}, Verse: "2"},
+		{Marker: `\qd`, Line: parser.Line{Style: "qd", Align: "left"}, Verse: "2", Continuation: true},
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("lines mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+
+	// The acrostic letter is a span of the continued verse
+	verse := content.Children[4].Children[0]
+	if n := len(verse.Children); n != 5 || !verse.Children[3].IsMarker("qac") {
+		t.Errorf("unexpected continued verse: %+v", verse.Children)
+	}
+}
+
+// Ensure text after a chapter does not continue the last verse of the
+// previous chapter.
+func TestParserPoetryChapter(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id PSA \c 3 \q1 \v 1 T1 \c 4 \q1 T2`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	line := content.Children[len(content.Children)-1]
+	if n := len(line.Children); n != 1 || line.Children[0].Kind() != parser.TextNode {
+		t.Errorf("expected text in the line, got %+v", line.Children)
+	}
+}
+
+// Ensure stanza breaks are kept in verses and paragraphs.
+func TestParserStanzaBreak(t *testing.T) {
+	s := `\id PSA \c 1 \q1 \v 1 T1 \b \q1 T2 \v 2 T3 \b \p \b \v 3 T4`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := `id(PSA) c(1) q1(v(1 T1 b())) q1(v(1 Sub-verse paragraph T2) v(2 T3 b())) p(b() v(3 T4))`
+	if got := outline(content.Children); got != exp {
+		t.Errorf("content mismatch:\n\nexp=%s\n\ngot=%s\n\n", exp, got)
+	}
+}
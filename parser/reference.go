@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Reference represents a scripture reference such as "Mat 3:1-4".
+type Reference struct {
+	// Text is the reference as written in the source
+	Text string
+
+	// Book is the USFM book code (empty if the book is unknown)
+	Book string
+
+	// Chapter is the first chapter
+	Chapter int
+
+	// Verse is the first verse (0 for a whole chapter)
+	Verse int
+
+	// EndChapter is the last chapter of a range (0 if not a range)
+	EndChapter int
+
+	// EndVerse is the last verse of a range (0 if not a range)
+	EndVerse int
+}
+
+// IsRange reports whether the reference covers more than a single verse or chapter.
+func (r Reference) IsRange() bool {
+	return r.EndChapter != 0 && (r.EndChapter != r.Chapter || r.EndVerse != r.Verse)
+}
+
+// OSIS returns the reference as an OSIS style ID such as "MAT.3.1-MAT.3.4".
+func (r Reference) OSIS() string {
+	id := r.Book + "." + strconv.Itoa(r.Chapter)
+	if r.Verse != 0 {
+		id += "." + strconv.Itoa(r.Verse)
+	}
+	if r.IsRange() {
+		id += "-" + r.Book + "." + strconv.Itoa(r.EndChapter)
+		if r.EndVerse != 0 {
+			id += "." + strconv.Itoa(r.EndVerse)
+		}
+	}
+	return id
+}
+
+// referenceBook matches a book name or abbreviation at the start of a reference
+var referenceBook = regexp.MustCompile(`^((?:[1-4]\s?)?\pL[\pL.\s]*?)\s*(\d.*)$`)
+
+// referencePart matches "3", "3:1", "4a" style chapter and verse numbers
+var referencePart = regexp.MustCompile(`^(\d+)[a-z]?(?:[:.](\d+)[a-z]?)?$`)
+
+// ParseReferences parses cross-reference target text such as
+// "Mat 3:1-4; 5:6, 8; Luk 2:5" into references. References without a book
+// name use the previous book or the given default book code. Enclosing
+// brackets, as in "(Mrk 1:1-8; Luk 3:1-18)", are ignored.
+func ParseReferences(text string, book string) []Reference {
+	var refs []Reference
+	chapter := 0
+	text = strings.Trim(strings.TrimSpace(text), "()[]")
+	for _, group := range strings.Split(text, ";") {
+		group = strings.TrimSpace(group)
+		if m := referenceBook.FindStringSubmatch(group); m != nil {
+			if code := LookupBook(m[1]); code != "" {
+				book = code
+				chapter = 0
+			}
+			group = m[2]
+		}
+
+		for _, item := range strings.Split(group, ",") {
+			item = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(item), "."))
+			if item == "" {
+				continue
+			}
+			ref := Reference{Text: item, Book: book}
+			bounds := strings.FieldsFunc(item, func(r rune) bool { return r == '-' || r == '–' })
+			if len(bounds) == 0 || len(bounds) > 2 {
+				continue
+			}
+
+			start := referencePart.FindStringSubmatch(strings.TrimSpace(bounds[0]))
+			if start == nil {
+				continue
+			}
+			if start[2] != "" {
+				ref.Chapter, _ = strconv.Atoi(start[1])
+				ref.Verse, _ = strconv.Atoi(start[2])
+			} else if chapter != 0 {
+				// A lone number after a chapter is another verse
+				ref.Chapter = chapter
+				ref.Verse, _ = strconv.Atoi(start[1])
+			} else {
+				ref.Chapter, _ = strconv.Atoi(start[1])
+			}
+
+			if len(bounds) == 2 {
+				end := referencePart.FindStringSubmatch(strings.TrimSpace(bounds[1]))
+				if end == nil {
+					continue
+				}
+				if end[2] != "" {
+					ref.EndChapter, _ = strconv.Atoi(end[1])
+					ref.EndVerse, _ = strconv.Atoi(end[2])
+				} else if ref.Verse != 0 {
+					ref.EndChapter = ref.Chapter
+					ref.EndVerse, _ = strconv.Atoi(end[1])
+				} else {
+					ref.EndChapter, _ = strconv.Atoi(end[1])
+				}
+			}
+
+			if ref.Verse != 0 {
+				chapter = ref.Chapter
+			}
+			if ref.EndVerse != 0 {
+				chapter = ref.EndChapter
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,51 @@
+package parser_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure cross-reference target text is parsed into references.
+func TestParseReferences(t *testing.T) {
+	var tests = []struct {
+		s    string
+		book string
+		osis []string
+	}{
+		{s: "Mat 3:1-4", osis: []string{"MAT.3.1-MAT.3.4"}},
+		{s: "Mat 3:1-4; 5:6, 8; Luk 2:5.", osis: []string{"MAT.3.1-MAT.3.4", "MAT.5.6", "MAT.5.8", "LUK.2.5"}},
+		{s: "1 Cor 13:4–7", osis: []string{"1CO.13.4-1CO.13.7"}},
+		{s: "Gen 1:31-2:3", osis: []string{"GEN.1.31-GEN.2.3"}},
+		{s: "Ps 23", osis: []string{"PSA.23"}},
+		{s: "Isa 40-42", osis: []string{"ISA.40-ISA.42"}},
+		{s: "3:16", book: "JHN", osis: []string{"JHN.3.16"}},
+		{s: "Jn 1:1a", osis: []string{"JHN.1.1"}},
+		{s: "(Mark 1:1-8; Luke 3:1-18)", osis: []string{"MRK.1.1-MRK.1.8", "LUK.3.1-LUK.3.18"}},
+		{s: "see above", osis: nil},
+	}
+
+	for i, tt := range tests {
+		var got []string
+		for _, ref := range parser.ParseReferences(tt.s, tt.book) {
+			got = append(got, ref.OSIS())
+		}
+		if !reflect.DeepEqual(tt.osis, got) {
+			t.Errorf("%d. %q: exp=%v got=%v", i, tt.s, tt.osis, got)
+		}
+	}
+}
+
+// Ensure book names and abbreviations resolve to book codes.
+func TestLookupBook(t *testing.T) {
+	var tests = map[string]string{
+		"MAT": "MAT", "Matthew": "MAT", "Mat.": "MAT", "1 Cor": "1CO",
+		"Jude": "JUD", "Judg": "JDG", "Phil": "PHP", "Jn": "JHN", "Xyz": "",
+	}
+	for name, code := range tests {
+		if got := parser.LookupBook(name); got != code {
+			t.Errorf("%q: exp=%q got=%q", name, code, got)
+		}
+	}
+}
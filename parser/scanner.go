@@ -3,23 +3,30 @@ package parser
 import (
 	"bufio"
 	"bytes"
-	"fmt"
 	"io"
-	"strings"
 	"unicode"
-	"unicode/utf8"
+	"unicode/utf16"
 )
 
 // Scanner represents a lexical scanner.
 type Scanner struct {
 	r        *bufio.Reader
+	markers  *Registry
+	loc      Location // location of the next rune
+	last     rune     // last rune read, to count "\r\n" as one line break
+	prev     Location // location before the last read (for unread)
+	prevLast rune     // last rune before the last read (for unread)
 	Pos      int
 	LastSize int
 }
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{
+		r:       bufio.NewReader(r),
+		markers: defaultMarkers,
+		loc:     Location{Line: 1, Column: 1, Column16: 1},
+	}
 }
 
 // read reads the next rune from the bufferred reader.
@@ -30,31 +37,50 @@ func (s *Scanner) read() rune {
 		return eof
 	}
 
+	// advance the location, "\r\n" is a single line break
+	s.prev, s.prevLast = s.loc, s.last
+	s.loc.Offset += bytes
+	if ch == '\n' && s.last == '\r' {
+	} else if ch == '\n' || ch == '\r' {
+		s.loc.Line++
+		s.loc.Column = 1
+		s.loc.Column16 = 1
+	} else {
+		s.loc.Column++
+		s.loc.Column16 += utf16.RuneLen(ch)
+	}
+	s.last = ch
+
 	// add the byte count to the position counter
-	s.Pos = s.Pos + bytes
+	s.Pos = s.loc.Offset
 	s.LastSize = bytes
 
 	return ch
 }
 
-// peek peeks the next byte - this doesn't advance the reader.
-func (s *Scanner) peek(i int) rune {
-	ch, err := s.r.Peek(i)
-	if err != nil {
-		return eof
-	}
-	r, _ := utf8.DecodeRune(ch)
-	return r
-}
-
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
 	_ = s.r.UnreadRune()
-	s.Pos = s.Pos - s.LastSize
+	s.loc, s.last = s.prev, s.prevLast
+	s.Pos = s.loc.Offset
 }
 
-// Scan returns the next token, literal value and position.
+// Scan returns the next token, literal value and byte position.
 func (s *Scanner) Scan() (tok Token, lit string, pos int) {
+	tok, lit, span := s.ScanSpan()
+	return tok, lit, span.Start.Offset
+}
+
+// ScanSpan returns the next token, literal value and source span.
+func (s *Scanner) ScanSpan() (tok Token, lit string, span Span) {
+	span.Start = s.loc
+	tok, lit = s.scan()
+	span.End = s.loc
+	return tok, lit, span
+}
+
+// scan returns the next token and literal value.
+func (s *Scanner) scan() (tok Token, lit string) {
 	// Read the next rune.
 	ch := s.read()
 
@@ -70,13 +96,8 @@ func (s *Scanner) Scan() (tok Token, lit string, pos int) {
 		s.unread()
 		return s.scanText()
 	} else if unicode.IsDigit(ch) {
-		ch2 := s.peek(1)
 		s.unread()
-		if isLetter(ch2) {
-			return s.scanText()
-		} else {
-			return s.scanNumber()
-		}
+		return s.scanNumber()
 	} else if isPipe(ch) {
 		s.unread()
 		return s.scanCitation()
@@ -84,121 +105,69 @@ func (s *Scanner) Scan() (tok Token, lit string, pos int) {
 
 	switch ch {
 	case eof:
-		return EOF, "", s.Pos - s.LastSize
+		return EOF, ""
 	}
 
-	return Illegal, string(ch), s.Pos - s.LastSize
+	return Illegal, string(ch)
 }
 
 // scanMarker consumes the current rune and read whole marker
-func (s *Scanner) scanMarker() (tok Token, lit string, pos int) {
+func (s *Scanner) scanMarker() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
 
-	// Read every subsequent non-whitespace character into the buffer.
-	// Whitespace character and EOF will cause the loop to exit.
-	for i := 0; i <= 6; i++ {
-		// FIXME: illegal?
-		if ch := s.read(); ch == eof {
-			break
-		} else if unicode.IsSpace(ch) {
-			s.unread()
-			break
-		} else if ch == 0x002A {
-			buf.WriteRune(ch)
-			break
-		} else {
-			buf.WriteRune(ch)
-		}
-		// Handle largest marker like \imte1
-		// anything beyond that is illegal
-		if i == 6 {
-			return Illegal, buf.String(), s.Pos - s.LastSize
+	// Read every subsequent marker character into the buffer.
+	// Whitespace, the next marker and EOF will cause the loop to exit.
+	if buf.String() != `¶` {
+		for {
+			if ch := s.read(); ch == eof {
+				break
+			} else if unicode.IsSpace(ch) || isBackslash(ch) {
+				s.unread()
+				break
+			} else if ch == 0x002A {
+				buf.WriteRune(ch)
+				break
+			} else {
+				buf.WriteRune(ch)
+			}
 		}
 	}
 
-	fmt.Printf("\nPosition: %v    Last Read Size: %v    Marker Buffer Length: %v    Marker: %v\n", s.Pos, s.LastSize, buf.Len(), buf.String())
-	fmt.Printf("\nMarker %v was scanned to %v byte position and we're going to calculate that it starts at %v\n", buf.String(), s.Pos, (s.Pos - (buf.Len() - 1)))
-
-	var size int
-	if s.Pos < 10 {
-		size = buf.Len()
-	} else {
-		size = buf.Len()
+	if buf.String() == `¶` {
+		return MarkerP, buf.String()
+	} else if buf.String() == `\*` {
+		return EndMilestone, buf.String()
 	}
 
-	position := s.Pos - size
-
-	switch strings.ToUpper(buf.String()) {
-	case `\ID`:
-		return MarkerID, buf.String(), position
-	case `\IDE`:
-		return MarkerIde, buf.String(), position
-	case `\IMTE`, `\IMTE1`:
-		return MarkerImte1, buf.String(), position
-	case `\D`:
-		return MarkerD, buf.String(), position
-	case `\H`:
-		return MarkerH, buf.String(), position
-	case `\C`:
-		return MarkerC, buf.String(), position
-	case `\V`:
-		return MarkerV, buf.String(), position
-	case `\P`, `¶`, `\M`, `\NB`:
-		return MarkerP, buf.String(), position
-	case `\B`:
-		return MarkerB, buf.String(), position
-	case `\S`, `\S1`:
-		return MarkerS, buf.String(), position
-	case `\SP`:
-		return MarkerSP, buf.String(), position
-	case `\Q1`:
-		return MarkerQ1, buf.String(), position
-	case `\Q2`:
-		return MarkerQ2, buf.String(), position
-	case `\QS`:
-		return MarkerQS, buf.String(), position
-	case `\QS*`:
-		return EndMarkerQS, buf.String(), position
-	case `\W`:
-		return MarkerW, buf.String(), position
-	case `\W*`:
-		return EndMarkerW, buf.String(), position
-	case `\WJ`:
-		return MarkerWJ, buf.String(), position
-	case `\WJ*`:
-		return EndMarkerWJ, buf.String(), position
-	case `\X`:
-		return MarkerX, buf.String(), position
-	case `\X*`:
-		return EndMarkerX, buf.String(), position
-	case `\XO`:
-		return MarkerXO, buf.String(), position
-	case `\XT`:
-		return MarkerXT, buf.String(), position
-	case `\F`:
-		return MarkerF, buf.String(), position
-	case `\F*`:
-		return EndMarkerF, buf.String(), position
-	case `\FR`:
-		return MarkerFR, buf.String(), position
-	case `\FT`:
-		return MarkerFT, buf.String(), position
-	case `\ADD`:
-		return MarkerAdd, buf.String(), position
-	case `\ADD*`:
-		return EndMarkerAdd, buf.String(), position
-	case `|`:
-		return Citation, buf.String(), position
+	return s.lookupMarker(buf.String()), buf.String()
+}
+
+// lookupMarker returns the token for a marker literal using the marker registry
+func (s *Scanner) lookupMarker(lit string) Token {
+	name, end := splitMarker(lit)
+	def, level := s.markers.Lookup(name)
+	if def == nil {
+		return Illegal
 	}
 
-	return Illegal, buf.String(), position
+	if end {
+		// Only character-level markers can be closed
+		if def.Kind != CharacterKind && def.Kind != NoteKind {
+			return Illegal
+		}
+		if def.EndToken != Illegal {
+			return def.EndToken
+		}
+		return EndMarker
+	}
 
+	return def.Token(level)
 }
 
 // scanWhitespace consumes the current rune and all contiguous whitespace.
-func (s *Scanner) scanWhitespace() (tok Token, lit string, pos int) {
+func (s *Scanner) scanWhitespace() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
@@ -216,11 +185,11 @@ func (s *Scanner) scanWhitespace() (tok Token, lit string, pos int) {
 		}
 	}
 
-	return Whitespace, buf.String(), s.Pos - buf.Len()
+	return Whitespace, buf.String()
 }
 
 // scanCitation consumes the current rune and all contiguous runes until it hits the next Marker.
-func (s *Scanner) scanCitation() (tok Token, lit string, pos int) {
+func (s *Scanner) scanCitation() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
@@ -238,11 +207,11 @@ func (s *Scanner) scanCitation() (tok Token, lit string, pos int) {
 		}
 	}
 
-	return Citation, buf.String(), s.Pos - buf.Len()
+	return Citation, buf.String()
 }
 
 // scanText consumes the current rune and all contiguous ident runes.
-func (s *Scanner) scanText() (tok Token, lit string, pos int) {
+func (s *Scanner) scanText() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
@@ -263,11 +232,13 @@ func (s *Scanner) scanText() (tok Token, lit string, pos int) {
 		}
 	}
 
-	return Text, buf.String(), s.Pos - buf.Len()
+	return Text, buf.String()
 }
 
 // scanNumber consumes the current rune and all contiguous number runes.
-func (s *Scanner) scanNumber() (tok Token, lit string, pos int) {
+// A number followed by letters or punctuation (e.g. "4a" or "1-3") is
+// returned as a single Text token.
+func (s *Scanner) scanNumber() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
@@ -285,7 +256,14 @@ func (s *Scanner) scanNumber() (tok Token, lit string, pos int) {
 		}
 	}
 
-	return Number, buf.String(), s.Pos - buf.Len()
+	if ch := s.read(); ch != eof {
+		s.unread()
+		if isLetter(ch) && !isBackslash(ch) {
+			_, text := s.scanText()
+			return Text, buf.String() + text
+		}
+	}
+	return Number, buf.String()
 }
 
 // isLetter returns true if the rune is backslash (\)
@@ -301,3 +279,6 @@ func isPipe(ch rune) bool { return ch == '|' }
 
 // eof represents a marker rune for the end of the reader.
 var eof = rune(0)
+
+// defaultMarkers is the registry shared by scanners using the USFM 3 marker set.
+var defaultMarkers = DefaultRegistry()
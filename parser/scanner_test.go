@@ -4,7 +4,7 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/baijum/usfm/parser"
+	"github.com/socceroos/usfm/parser"
 )
 
 // Ensure the scanner can scan tokens correctly.
@@ -26,17 +26,71 @@ func TestScan(t *testing.T) {
 		{s: `\id`, tok: parser.MarkerID, lit: `\id`},
 		{s: `\imte`, tok: parser.MarkerImte1, lit: `\imte`},
 		{s: `\imte1`, tok: parser.MarkerImte1, lit: `\imte1`},
+		{s: `\mt1`, tok: parser.Marker, lit: `\mt1`},
+		{s: `\toc2`, tok: parser.Marker, lit: `\toc2`},
+		{s: `\s2`, tok: parser.MarkerS, lit: `\s2`},
+		{s: `\q2`, tok: parser.MarkerQ2, lit: `\q2`},
+		{s: `\q4`, tok: parser.MarkerQ4, lit: `\q4`},
+		{s: `\m`, tok: parser.MarkerM, lit: `\m`},
+		{s: `\nb`, tok: parser.MarkerNB, lit: `\nb`},
+		{s: `¶`, tok: parser.MarkerP, lit: `¶`},
+		{s: `\qt1-s`, tok: parser.Marker, lit: `\qt1-s`},
+		{s: `\*`, tok: parser.EndMilestone, lit: `\*`},
+		{s: `\nd*`, tok: parser.EndMarker, lit: `\nd*`},
+		{s: `\wj*`, tok: parser.EndMarkerWJ, lit: `\wj*`},
+		{s: `\fig`, tok: parser.MarkerFig, lit: `\fig`},
+		{s: `\fig*`, tok: parser.EndMarkerFig, lit: `\fig*`},
+		{s: `\+nd`, tok: parser.Marker, lit: `\+nd`},
+		{s: `\+wj*`, tok: parser.EndMarkerWJ, lit: `\+wj*`},
+		{s: `\p*`, tok: parser.Illegal, lit: `\p*`},
+		{s: `\mt9`, tok: parser.Illegal, lit: `\mt9`},
+		{s: `\nd\nd*`, tok: parser.Marker, lit: `\nd`},
 		{s: "123", tok: parser.Number, lit: "123"},
+		{s: "12a", tok: parser.Text, lit: "12a"},
+		{s: "1-3 T1", tok: parser.Text, lit: "1-3"},
+		{s: "12 T1", tok: parser.Number, lit: "12"},
 		{s: "Jesus", tok: parser.Text, lit: "Jesus"},
 	}
 
 	for i, tt := range tests {
 		s := parser.NewScanner(strings.NewReader(tt.s))
-		tok, lit := s.Scan()
+		tok, lit, _ := s.Scan()
 		if tt.tok != tok {
-			t.Errorf("%d. %q token mismatch: exp=%q got=%q <%q>", i, tt.s, tt.tok, tok, lit)
+			t.Errorf("%d. %q token mismatch: exp=%d got=%d <%q>", i, tt.s, tt.tok, tok, lit)
 		} else if tt.lit != lit {
 			t.Errorf("%d. %q literal mismatch: exp=%q got=%q", i, tt.s, tt.lit, lit)
 		}
 	}
 }
+
+// Ensure the scanner tracks source spans across CRLF and multibyte characters.
+func TestScanSpan(t *testing.T) {
+	type loc = parser.Location
+	var tests = []struct {
+		tok   parser.Token
+		lit   string
+		start parser.Location
+		end   parser.Location
+	}{
+		{tok: parser.MarkerV, lit: `\v`, start: loc{Offset: 0, Line: 1, Column: 1, Column16: 1}, end: loc{Offset: 2, Line: 1, Column: 3, Column16: 3}},
+		{tok: parser.Whitespace, lit: " ", start: loc{Offset: 2, Line: 1, Column: 3, Column16: 3}, end: loc{Offset: 3, Line: 1, Column: 4, Column16: 4}},
+		{tok: parser.Number, lit: "1", start: loc{Offset: 3, Line: 1, Column: 4, Column16: 4}, end: loc{Offset: 4, Line: 1, Column: 5, Column16: 5}},
+		{tok: parser.Whitespace, lit: " ", start: loc{Offset: 4, Line: 1, Column: 5, Column16: 5}, end: loc{Offset: 5, Line: 1, Column: 6, Column16: 6}},
+		{tok: parser.Text, lit: "θεός", start: loc{Offset: 5, Line: 1, Column: 6, Column16: 6}, end: loc{Offset: 13, Line: 1, Column: 10, Column16: 10}},
+		{tok: parser.Whitespace, lit: "\r\n", start: loc{Offset: 13, Line: 1, Column: 10, Column16: 10}, end: loc{Offset: 15, Line: 2, Column: 1, Column16: 1}},
+		{tok: parser.Text, lit: "𝔄", start: loc{Offset: 15, Line: 2, Column: 1, Column16: 1}, end: loc{Offset: 19, Line: 2, Column: 2, Column16: 3}},
+		{tok: parser.MarkerP, lit: `\p`, start: loc{Offset: 19, Line: 2, Column: 2, Column16: 3}, end: loc{Offset: 21, Line: 2, Column: 4, Column16: 5}},
+		{tok: parser.Whitespace, lit: "\n\n", start: loc{Offset: 21, Line: 2, Column: 4, Column16: 5}, end: loc{Offset: 23, Line: 4, Column: 1, Column16: 1}},
+		{tok: parser.EOF, lit: "", start: loc{Offset: 23, Line: 4, Column: 1, Column16: 1}, end: loc{Offset: 23, Line: 4, Column: 1, Column16: 1}},
+	}
+
+	s := parser.NewScanner(strings.NewReader("\\v 1 θεός\r\n𝔄\\p\n\n"))
+	for i, tt := range tests {
+		tok, lit, span := s.ScanSpan()
+		if tt.tok != tok || tt.lit != lit {
+			t.Errorf("%d. token mismatch: exp=%d %q got=%d %q", i, tt.tok, tt.lit, tok, lit)
+		} else if tt.start != span.Start || tt.end != span.End {
+			t.Errorf("%d. %q span mismatch: exp=%+v-%+v got=%+v-%+v", i, lit, tt.start, tt.end, span.Start, span.End)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package parser
+
+// Category returns the category of an extended note or sidebar given by its
+// \cat marker, or an empty string.
+func (c *Content) Category() string {
+	return c.Attributes["category"]
+}
+
+// setCategory sets the "category" attribute of a note or sidebar from its
+// first \cat marker.
+func setCategory(node *Content) {
+	var cat *Content
+	Inspect(node, func(c *Cursor) bool {
+		if cat == nil && c.Node.IsMarker("cat") {
+			cat = c.Node
+		}
+		return cat == nil && (c.Node == node || !c.Node.IsNote())
+	})
+	if cat == nil {
+		return
+	}
+	if node.Attributes == nil {
+		node.Attributes = map[string]string{}
+	}
+	node.Attributes["category"] = textOf(cat)
+}
+
+// parseSidebar reads the markers of a sidebar up to \esbe into the sidebar
+// node. The text of a sidebar does not continue the verse before it.
+func (p *Parser) parseSidebar(sidebar *Content) error {
+	verse := p.verse
+	p.verse = nil
+	defer func() { p.verse = verse }()
+	defer setCategory(sidebar)
+
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		if tok == EOF || tok == MarkerID || tok == MarkerC {
+			p.warn(Diagnostic{Code: CodeUnclosedMarker, Message: `\esb is not closed, expected \esbe`, Span: sidebar.Span, Found: tok, Literal: lit})
+			p.unscan()
+			return nil
+		} else if p.isSidebarEnd(tok, lit) {
+			sidebar.Span.End = span.End
+			return nil
+		} else if p.kind(tok, lit) == CharacterKind {
+			// The category (\esb \cat People\cat*) and any other inline
+			// markers before the first paragraph
+			p.parseCharacter(sidebar, lit, span)
+			continue
+		}
+		p.unscan()
+		if err := p.parseNext(sidebar); err != nil {
+			return err
+		}
+	}
+}
+
+// isSidebarEnd reports whether the token is the \esbe marker ending a
+// sidebar.
+func (p *Parser) isSidebarEnd(tok Token, lit string) bool {
+	name, end := splitMarker(lit)
+	if tok == Illegal || end {
+		return false
+	}
+	def, _ := p.markers.Lookup(name)
+	return def != nil && def.Name == "esbe"
+}
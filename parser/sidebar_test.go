@@ -0,0 +1,70 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure sidebars group their markers and notes and sidebars get their category.
+func TestParserSidebar(t *testing.T) {
+	s := `\id GEN \c 1 \p \v 1 T1\ef - \cat People\cat*\ft T2\ef* T3\ex - \cat Places\cat*\xt Joh 1:1\ex*
+\esb \cat History\cat* \ms T4 \p T5 \esbe \p T6`
+	p := parser.NewParser(strings.NewReader(s))
+	content, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if d := p.Diagnostics(); len(d) != 0 {
+		t.Errorf("unexpected diagnostics: %+v", d)
+	}
+
+	var types []string
+	for _, c := range content.Children {
+		types = append(types, c.Type+" "+c.Value)
+	}
+	if exp := []string{`marker \id`, `marker \c`, `marker \p`, "sidebar ", `marker \p`}; !reflect.DeepEqual(exp, types) {
+		t.Fatalf("book mismatch: exp=%q got=%q", exp, types)
+	}
+
+	var got []string
+	parser.Inspect(content, func(c *parser.Cursor) bool {
+		if cat := c.Node.Category(); cat != "" {
+			got = append(got, c.Node.Type+" "+c.Node.Value+" "+cat)
+		}
+		return true
+	})
+	if exp := []string{`marker \ef People`, `marker \ex Places`, "sidebar  History"}; !reflect.DeepEqual(exp, got) {
+		t.Errorf("categories mismatch: exp=%q got=%q", exp, got)
+	}
+
+	// The sidebar does not continue verse 1, the paragraph after it does
+	sidebar := content.Children[3]
+	if got := outline(sidebar.Children); got != `cat(History) ms(T4) p(T5)` {
+		t.Errorf("sidebar mismatch: %s", got)
+	}
+	if got := outline(content.Children[4].Children); got != `v(1 Sub-verse paragraph T6)` {
+		t.Errorf("paragraph mismatch: %s", got)
+	}
+}
+
+// Ensure sidebars without an end and ends without a sidebar are reported.
+func TestParserSidebar_Unmatched(t *testing.T) {
+	p := parser.NewParser(strings.NewReader(`\id GEN \esbe \c 1 \esb \p T1 \c 2 \p T2`))
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, d := range p.Diagnostics() {
+		got = append(got, d.Code+" "+d.Message)
+	}
+	exp := []string{
+		`unmatched-marker \esbe has no matching start`,
+		`unclosed-marker \esb is not closed, expected \esbe`,
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("diagnostics mismatch: exp=%q got=%q", exp, got)
+	}
+}
@@ -0,0 +1,65 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure character spans nest with \+, close implicitly and report stray
+// closing markers.
+func TestParserCharacterSpans(t *testing.T) {
+	var tests = []struct {
+		s     string
+		verse string
+		diags []string
+	}{
+		{s: `\wj Love the \+nd Lord\+nd* your God\wj* T1`, verse: `wj(Love the nd(Lord) your God) T1`},
+		{s: `\nd T1 \bd T2\bd* T3 \sc T4 \it T5\it*`, verse: `nd(T1) bd(T2) T3 sc(T4) it(T5)`},
+		{s: `\wj T1 \+nd T2 \+bd T3\+bd*\+nd* T4\wj*`, verse: `wj(T1 nd(T2 bd(T3)) T4)`},
+		{s: `\wj T1 \+nd T2\wj* T3`, verse: `wj(T1 nd(T2)) T3`, diags: []string{`\nd is not closed, expected \nd*`}},
+		{s: `\wj T1 \f + \ft T2 \+bd T3\+bd*\f* T4\wj*`, verse: `wj(T1 f(+ ft(T2 bd(T3))) T4)`},
+		{s: `\f + \ft T1 \bd T2\f* T3`, verse: `f(+ ft(T1 bd(T2))) T3`, diags: []string{`\bd is not closed, expected \bd*`}},
+		{s: `\wj T1 \w T2\w*\wj* T3`, verse: `wj(T1) w(T2) T3`, diags: []string{`\wj* has no matching start`}},
+		{s: `\pn T1 \k T2 \v 2 T3`, verse: `pn(T1) k(T2)`, diags: []string{`\k is not closed, expected \k*`}},
+	}
+
+	for i, tt := range tests {
+		p := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 ` + tt.s))
+		content, err := p.Parse()
+		if err != nil {
+			t.Errorf("%d. %q: unexpected error: %v", i, tt.s, err)
+			continue
+		}
+
+		v := content.Children[2].Children[0]
+		if got := outline(v.Children[1:]); got != tt.verse {
+			t.Errorf("%d. %q: verse mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, tt.verse, got)
+		}
+		var diags []string
+		for _, d := range p.Diagnostics() {
+			diags = append(diags, d.Message)
+		}
+		if !reflect.DeepEqual(tt.diags, diags) {
+			t.Errorf("%d. %q: diagnostics mismatch: exp=%q got=%q", i, tt.s, tt.diags, diags)
+		}
+	}
+}
+
+// outline writes nodes as text, with the children of markers and groups in
+// brackets.
+func outline(nodes []*parser.Content) string {
+	var words []string
+	for _, c := range nodes {
+		if name := c.Marker(); name != "" {
+			words = append(words, name+"("+outline(c.Children)+")")
+		} else if len(c.Children) > 0 {
+			words = append(words, c.Type+"("+outline(c.Children)+")")
+		} else {
+			words = append(words, c.Value)
+		}
+	}
+	return strings.Join(words, " ")
+}
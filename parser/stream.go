@@ -0,0 +1,295 @@
+package parser
+
+import "io"
+
+// EventType is the type of a streaming parse Event.
+type EventType int
+
+const (
+	// EventStartBook starts a book; the Node is the book node (without children)
+	EventStartBook EventType = iota
+
+	// EventEndBook ends a book
+	EventEndBook
+
+	// EventStartChapter starts a chapter at a \c marker
+	EventStartChapter
+
+	// EventEndChapter ends a chapter before the next chapter or book
+	EventEndChapter
+
+	// EventStartParagraph starts a paragraph marker such as \p or \q1
+	EventStartParagraph
+
+	// EventEndParagraph ends a paragraph
+	EventEndParagraph
+
+	// EventStartVerse starts a verse marker
+	EventStartVerse
+
+	// EventEndVerse ends a verse
+	EventEndVerse
+
+	// EventStartChar starts a character marker such as \wj or \nd
+	EventStartChar
+
+	// EventEndChar ends a character marker
+	EventEndChar
+
+	// EventStartNote starts a footnote, endnote or cross reference
+	EventStartNote
+
+	// EventEndNote ends a note
+	EventEndNote
+
+	// EventStartMarker starts any other marker (e.g. \id, \h or \mt1),
+	// including unknown markers
+	EventStartMarker
+
+	// EventEndMarker ends any other marker
+	EventEndMarker
+
+	// EventText is a text node (text, headings, descriptions and speakers)
+	EventText
+
+	// EventValue is any other node without children (e.g. a verse number,
+	// caller or attribute list)
+	EventValue
+
+	// EventStartTable starts a table of \tr rows
+	EventStartTable
+
+	// EventEndTable ends a table
+	EventEndTable
+
+	// EventStartIntroduction starts the introduction of a book
+	EventStartIntroduction
+
+	// EventEndIntroduction ends the introduction of a book
+	EventEndIntroduction
+
+	// EventStartList starts a list of \li# items
+	EventStartList
+
+	// EventEndList ends a list
+	EventEndList
+
+	// EventStartSidebar starts a study bible sidebar
+	EventStartSidebar
+
+	// EventEndSidebar ends a sidebar
+	EventEndSidebar
+
+	// EventStartPeripheral starts a division of a peripheral book (\periph)
+	EventStartPeripheral
+
+	// EventEndPeripheral ends a peripheral division
+	EventEndPeripheral
+)
+
+var eventTypes = []string{
+	"StartBook", "EndBook", "StartChapter", "EndChapter", "StartParagraph", "EndParagraph",
+	"StartVerse", "EndVerse", "StartChar", "EndChar", "StartNote", "EndNote",
+	"StartMarker", "EndMarker", "Text", "Value", "StartTable", "EndTable",
+	"StartIntroduction", "EndIntroduction", "StartList", "EndList",
+	"StartSidebar", "EndSidebar", "StartPeripheral", "EndPeripheral",
+}
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	if t < 0 || int(t) >= len(eventTypes) {
+		return ""
+	}
+	return eventTypes[t]
+}
+
+// Event is emitted by Stream for each part of the source. The embedded
+// Cursor gives the node, its ancestors and the current reference. An Event
+// is only valid until the callback returns.
+type Event struct {
+	Type EventType
+	Cursor
+}
+
+// Stream parses the source like Parse but emits events instead of building
+// the whole tree. Each top level marker (e.g. a paragraph) is parsed, sent to
+// fn and then dropped, so concatenated books are processed with constant
+// memory. Returning false from fn stops the parse. Introductions, lists and
+// headings are sent once the next markers can't add to them anymore.
+func (p *Parser) Stream(fn func(e *Event) bool) error {
+	s := &streamer{fn: fn, markers: p.markers}
+	book := &Content{}
+	for !s.stopped {
+		err := p.parseNext(book)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		n := len(book.Children)
+		if n > 0 && p.isOpen(book.Children[n-1]) {
+			n--
+		}
+		s.send(p, book, book.Children[:n])
+		book.Children = append(book.Children[:0], book.Children[n:]...)
+	}
+	s.send(p, book, book.Children)
+	if s.stopped {
+		return nil
+	}
+	s.endBook()
+	p.closeMilestones()
+	return p.err()
+}
+
+// streamer is the Visitor which turns parsed nodes into events.
+type streamer struct {
+	Event
+	fn      func(e *Event) bool
+	markers *Registry
+	book    *Content // current book node (nil between books)
+	chapter *Content // current chapter node (nil outside chapters)
+	stopped bool
+}
+
+// send walks the top level nodes parsed into book.
+func (s *streamer) send(p *Parser, book *Content, nodes []*Content) {
+	for _, node := range nodes {
+		if s.stopped {
+			return
+		}
+		finishSpans(node)
+		if p.markers != defaultMarkers {
+			setMarkers(node, p.markers)
+		}
+		if node.IsMarker("id") {
+			s.endBook()
+		} else if node.IsMarker("c") {
+			s.endChapter()
+		}
+		s.startBook(book.Value, book.Position)
+		walk(node, s, &s.Cursor)
+		s.Ancestors = s.Ancestors[:1]
+	}
+}
+
+// emit sends an event unless the stream was stopped.
+func (s *streamer) emit(t EventType, node *Content) {
+	if s.stopped {
+		return
+	}
+	s.Type = t
+	s.Node = node
+	if !s.fn(&s.Event) {
+		s.stopped = true
+	}
+}
+
+func (s *streamer) startBook(code string, position int) {
+	if s.book == nil {
+		s.book = &Content{Type: BookNode.String(), Value: code, Position: position}
+		s.Book = code
+		s.Ancestors = append(s.Ancestors[:0], s.book)
+		s.emit(EventStartBook, s.book)
+	}
+}
+
+func (s *streamer) endBook() {
+	s.endChapter()
+	if s.book != nil {
+		s.emit(EventEndBook, s.book)
+		s.book = nil
+		s.Book, s.Chapter, s.Verse = "", "", ""
+	}
+}
+
+func (s *streamer) endChapter() {
+	if s.chapter != nil {
+		s.emit(EventEndChapter, s.chapter)
+		s.chapter = nil
+	}
+}
+
+// Enter emits the start event of a node.
+func (s *streamer) Enter(c *Cursor) bool {
+	node := c.Node
+	if node.Kind() == TableNode {
+		s.emit(EventStartTable, node)
+		return !s.stopped
+	} else if node.Kind() == IntroductionNode {
+		s.emit(EventStartIntroduction, node)
+		return !s.stopped
+	} else if node.Kind() == ListNode {
+		s.emit(EventStartList, node)
+		return !s.stopped
+	} else if node.Kind() == SidebarNode {
+		s.emit(EventStartSidebar, node)
+		return !s.stopped
+	} else if node.Kind() == PeripheralNode {
+		s.emit(EventStartPeripheral, node)
+		return !s.stopped
+	} else if k := node.Kind(); k != MarkerNode && k != UnknownMarkerNode {
+		if k == TextNode || k == HeadingNode || k == DescriptionNode || k == SpeakerNode {
+			s.emit(EventText, node)
+		} else {
+			s.emit(EventValue, node)
+		}
+		return !s.stopped
+	}
+
+	switch s.markers.Kind(node.Value) {
+	case ChapterKind:
+		s.chapter = node
+		s.emit(EventStartChapter, node)
+	case ParagraphKind:
+		s.emit(EventStartParagraph, node)
+	case VerseKind:
+		s.emit(EventStartVerse, node)
+	case CharacterKind:
+		s.emit(EventStartChar, node)
+	case NoteKind:
+		s.emit(EventStartNote, node)
+	default:
+		s.emit(EventStartMarker, node)
+	}
+	return !s.stopped
+}
+
+// Leave emits the end event of a node.
+func (s *streamer) Leave(c *Cursor) {
+	node := c.Node
+	if node.Kind() == TableNode {
+		s.emit(EventEndTable, node)
+		return
+	} else if node.Kind() == IntroductionNode {
+		s.emit(EventEndIntroduction, node)
+		return
+	} else if node.Kind() == ListNode {
+		s.emit(EventEndList, node)
+		return
+	} else if node.Kind() == SidebarNode {
+		s.emit(EventEndSidebar, node)
+		return
+	} else if node.Kind() == PeripheralNode {
+		s.emit(EventEndPeripheral, node)
+		return
+	} else if k := node.Kind(); k != MarkerNode && k != UnknownMarkerNode {
+		return
+	}
+
+	switch s.markers.Kind(node.Value) {
+	case ChapterKind:
+		// The chapter ends at the next chapter or book
+	case ParagraphKind:
+		s.emit(EventEndParagraph, node)
+	case VerseKind:
+		s.emit(EventEndVerse, node)
+	case CharacterKind:
+		s.emit(EventEndChar, node)
+	case NoteKind:
+		s.emit(EventEndNote, node)
+	default:
+		s.emit(EventEndMarker, node)
+	}
+}
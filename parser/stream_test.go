@@ -0,0 +1,133 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure Stream emits events for concatenated books.
+func TestStream(t *testing.T) {
+	p := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 \wj T1\wj* \c 2 \p \v 1 T2 \id MRK \c 1 \p \v 1 T3`))
+	var got []string
+	err := p.Stream(func(e *parser.Event) bool {
+		if e.Type != parser.EventValue {
+			got = append(got, e.Type.String()+" "+e.Node.Value+" "+e.Reference())
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := []string{
+		"StartBook MAT MAT", `StartMarker \id MAT`, `EndMarker \id MAT`,
+		`StartChapter \c MAT.1`,
+		`StartParagraph \p MAT.1`, `StartVerse \v MAT.1.1`, `StartChar \wj MAT.1.1`, "Text T1 MAT.1.1", `EndChar \wj MAT.1.1`, `EndVerse \v MAT.1.1`, `EndParagraph \p MAT.1.1`,
+		`EndChapter \c MAT.1.1`, `StartChapter \c MAT.2`,
+		`StartParagraph \p MAT.2`, `StartVerse \v MAT.2.1`, "Text T2 MAT.2.1", `EndVerse \v MAT.2.1`, `EndParagraph \p MAT.2.1`,
+		`EndChapter \c MAT.2.1`, "EndBook MAT MAT.2.1",
+		"StartBook MRK MRK", `StartMarker \id MRK`, `EndMarker \id MRK`,
+		`StartChapter \c MRK.1`,
+		`StartParagraph \p MRK.1`, `StartVerse \v MRK.1.1`, "Text T3 MRK.1.1", `EndVerse \v MRK.1.1`, `EndParagraph \p MRK.1.1`,
+		`EndChapter \c MRK.1.1`, "EndBook MRK MRK.1.1",
+	}
+	if !reflect.DeepEqual(exp, got) {
+		t.Errorf("event mismatch:\n\nexp=%q\n\ngot=%q\n\n", exp, got)
+	}
+}
+
+// Ensure returning false from the callback stops the stream.
+func TestStreamStop(t *testing.T) {
+	p := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 T1 \v 2 T2 \v 3 T3`))
+	var verses []string
+	err := p.Stream(func(e *parser.Event) bool {
+		if e.Type == parser.EventStartVerse {
+			verses = append(verses, e.Verse)
+		}
+		return e.Verse != "2"
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := []string{"1", "2"}; !reflect.DeepEqual(exp, verses) {
+		t.Errorf("verse mismatch: exp=%q got=%q", exp, verses)
+	}
+}
+
+// Ensure Stream returns parse errors.
+func TestStreamError(t *testing.T) {
+	p := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v X`))
+	var n int
+	err := p.Stream(func(e *parser.Event) bool {
+		n++
+		return true
+	})
+	if exp := `found "X", expected verse number`; errstring(err) != exp {
+		t.Errorf("error mismatch: exp=%q got=%q", exp, errstring(err))
+	}
+	if n == 0 {
+		t.Errorf("expected events before the error")
+	}
+}
+
+// Ensure Stream sends the same tree as Parse for markers which continue the
+// previous group.
+func TestStreamGroups(t *testing.T) {
+	var tests = []struct {
+		s string
+	}{
+		// Introduction of several paragraphs
+		{s: `\id MAT \imt1 T1 \is1 T2 \ip T3 \ip T4 \ie \mt1 T5`},
+		{s: `\id MAT \imt1 T1 \ip T2 \ie \ip T3 \ip T4`},
+
+		// List of several items
+		{s: `\id NUM \lh T1 \li1 T2 \li2 T3 \li1 T4 \lf T5 \p T6`},
+		{s: `\id NUM \li1 T1 \li1 T2`},
+
+		// Heading with references
+		{s: `\id MAT \ms T1 \mr Psa 1 \s1 T3 \r Joh 1:1 \p T4`},
+		{s: `\id MAT \s1 T1 \r Joh 1:1 \sr Mat 1:1`},
+	}
+
+	for i, tt := range tests {
+		book, err := parser.NewParser(strings.NewReader(tt.s)).Parse()
+		if err != nil {
+			t.Fatalf("%d. %q: unexpected error: %v", i, tt.s, err)
+		}
+		got, err := streamOutline(tt.s)
+		if err != nil {
+			t.Fatalf("%d. %q: unexpected error: %v", i, tt.s, err)
+		}
+		if exp := outline(book.Children); exp != got {
+			t.Errorf("%d. %q: events mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, exp, got)
+		}
+	}
+}
+
+// streamOutline writes the events of Stream like outline writes the tree of
+// Parse, without the book.
+func streamOutline(s string) (string, error) {
+	stack := [][]string{nil}
+	err := parser.NewParser(strings.NewReader(s)).Stream(func(e *parser.Event) bool {
+		switch name := e.Type.String(); {
+		case e.Type == parser.EventStartBook || e.Type == parser.EventEndBook:
+		case strings.HasPrefix(name, "Start"):
+			stack = append(stack, nil)
+		case strings.HasPrefix(name, "End"):
+			words := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			label := e.Node.Marker()
+			if label == "" {
+				label = e.Node.Type
+			}
+			stack[len(stack)-1] = append(stack[len(stack)-1], label+"("+strings.Join(words, " ")+")")
+		default:
+			stack[len(stack)-1] = append(stack[len(stack)-1], e.Node.Value)
+		}
+		return true
+	})
+	return strings.Join(stack[0], " "), err
+}
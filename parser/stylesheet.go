@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Style is a marker definition of a Paratext stylesheet (usfm.sty or
+// custom.sty).
+type Style struct {
+	// Marker is the marker without the backslash (\Marker, e.g. "zq1")
+	Marker string
+
+	// Endmarker is the closing marker (\Endmarker, e.g. "wj*" or "qt-e")
+	Endmarker string
+
+	// Name is the display name of the style (\Name)
+	Name string
+
+	// Description describes the use of the style (\Description)
+	Description string
+
+	// StyleType is "Paragraph", "Character", "Note" or "Milestone"
+	// (\StyleType)
+	StyleType string
+
+	// TextType is the kind of text, e.g. "VerseText", "NoteText", "Section"
+	// or "Other" (\TextType)
+	TextType string
+
+	// OccursUnder lists the markers the marker may be nested in (\OccursUnder)
+	OccursUnder []string
+
+	// Attributes lists the attribute names (\Attributes), the first one is
+	// the default attribute. Optional attributes keep their "?" prefix.
+	Attributes []string
+
+	// Properties holds the other properties by lower case name, such as
+	// "fontsize", "bold" or "justification" (empty for flags like \Bold)
+	Properties map[string]string
+}
+
+// Stylesheet holds the styles of one or more Paratext stylesheets.
+type Stylesheet struct {
+	// Styles lists the styles in order of their first definition
+	Styles []*Style
+
+	byMarker map[string]*Style
+}
+
+// ParseStylesheet reads a Paratext stylesheet. Properties of a marker
+// defined again override the earlier definition, so a custom.sty can be
+// read after usfm.sty with io.MultiReader.
+func ParseStylesheet(r io.Reader) (*Stylesheet, error) {
+	s := &Stylesheet{byMarker: map[string]*Style{}}
+	var style *Style
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, `\`) {
+			continue
+		}
+
+		key, value := line[1:], ""
+		if i := strings.IndexAny(key, " \t"); i >= 0 {
+			key, value = key[:i], strings.TrimSpace(key[i:])
+		}
+		key = strings.ToLower(key)
+		if key == "marker" {
+			if value == "" {
+				return nil, fmt.Errorf("line %d: missing marker name", n)
+			}
+			if style = s.Lookup(value); style == nil {
+				style = &Style{Marker: value, Properties: map[string]string{}}
+				s.Styles = append(s.Styles, style)
+				s.byMarker[strings.ToLower(value)] = style
+			}
+			continue
+		} else if style == nil {
+			return nil, fmt.Errorf(`line %d: \%s before \Marker`, n, key)
+		}
+
+		switch key {
+		case "endmarker":
+			style.Endmarker = value
+		case "name":
+			style.Name = value
+		case "description":
+			style.Description = value
+		case "styletype":
+			style.StyleType = value
+		case "texttype":
+			style.TextType = value
+		case "occursunder":
+			style.OccursUnder = strings.Fields(value)
+		case "attributes":
+			style.Attributes = strings.Fields(value)
+		default:
+			style.Properties[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Lookup returns the style of a marker (e.g. "p" or "zq1"), or nil.
+func (s *Stylesheet) Lookup(marker string) *Style {
+	return s.byMarker[strings.ToLower(marker)]
+}
+
+// Registry returns the USFM 3 marker set with the markers of the
+// stylesheet. Markers unknown to USFM 3 (such as \z markers) are added with
+// the kind of their style type. Known markers keep their kind, the
+// stylesheet may only close them and change where they occur and their
+// default attribute.
+func (s *Stylesheet) Registry() *Registry {
+	r := DefaultRegistry()
+	for _, style := range s.Styles {
+		def, _ := r.Lookup(style.Marker)
+		if def == nil {
+			def = &MarkerDef{Name: style.Marker, Kind: style.kind(), TextType: style.textType()}
+			r.Register(def)
+			if def.Kind == MilestoneKind && style.Endmarker != "" {
+				r.Register(&MarkerDef{Name: style.Endmarker, Kind: MilestoneKind})
+			}
+		}
+		if style.Endmarker != "" && (def.Kind == CharacterKind || def.Kind == NoteKind) {
+			def.Closed = true
+		}
+		if len(style.OccursUnder) > 0 {
+			def.OccursUnder = style.OccursUnder
+		}
+		if len(style.Attributes) > 0 {
+			def.DefaultAttribute = strings.TrimPrefix(style.Attributes[0], "?")
+		}
+	}
+	return r
+}
+
+// kind returns the marker kind of the style type.
+func (s *Style) kind() MarkerKind {
+	switch strings.ToLower(s.StyleType) {
+	case "character":
+		return CharacterKind
+	case "note":
+		return NoteKind
+	case "milestone":
+		return MilestoneKind
+	}
+	return ParagraphKind
+}
+
+// textType returns the text type of the style.
+func (s *Style) textType() TextType {
+	switch strings.ToLower(s.TextType) {
+	case "versetext":
+		return TextVerse
+	case "notetext":
+		return TextNote
+	case "section":
+		return TextSection
+	case "title", "booktitle":
+		return TextTitle
+	}
+	return TextOther
+}
@@ -0,0 +1,173 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+const customSty = `# Custom styles
+\Marker zq1
+\Name zq1 - Poetry - Custom
+\TextType VerseText
+\StyleType Paragraph
+\LeftMargin .5
+
+\Marker zwj
+\Endmarker zwj*
+\StyleType Character
+\TextType VerseText
+\Bold
+\Attributes ?gloss
+
+\Marker zm-s
+\Endmarker zm-e
+\StyleType Milestone
+\Attributes who
+
+\Marker zq1
+\fontsize 14  # larger
+`
+
+// Ensure stylesheet properties are read and later definitions override them.
+func TestParseStylesheet(t *testing.T) {
+	s, err := parser.ParseStylesheet(strings.NewReader(customSty))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(s.Styles); n != 3 {
+		t.Fatalf("expected 3 styles, got %d", n)
+	}
+
+	exp := &parser.Style{
+		Marker:     "zq1",
+		Name:       "zq1 - Poetry - Custom",
+		StyleType:  "Paragraph",
+		TextType:   "VerseText",
+		Properties: map[string]string{"leftmargin": ".5", "fontsize": "14"},
+	}
+	if got := s.Lookup("ZQ1"); !reflect.DeepEqual(exp, got) {
+		t.Errorf("style mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+	exp = &parser.Style{
+		Marker:     "zwj",
+		Endmarker:  "zwj*",
+		StyleType:  "Character",
+		TextType:   "VerseText",
+		Attributes: []string{"?gloss"},
+		Properties: map[string]string{"bold": ""},
+	}
+	if got := s.Lookup("zwj"); !reflect.DeepEqual(exp, got) {
+		t.Errorf("style mismatch:\n\nexp=%+v\n\ngot=%+v\n\n", exp, got)
+	}
+	if s.Lookup("zx") != nil {
+		t.Errorf("expected no style for zx")
+	}
+
+	if _, err := parser.ParseStylesheet(strings.NewReader(`\Bold`)); err == nil || err.Error() != `line 1: \bold before \Marker` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the markers of a stylesheet are registered with their style type.
+func TestStylesheet_Registry(t *testing.T) {
+	s, err := parser.ParseStylesheet(strings.NewReader(customSty + "\\Marker wj\n\\Italic\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := s.Registry()
+	for name, exp := range map[string]parser.MarkerKind{
+		"zq1":  parser.ParagraphKind,
+		"zwj":  parser.CharacterKind,
+		"zm-s": parser.MilestoneKind,
+		"zm-e": parser.MilestoneKind,
+		"wj":   parser.CharacterKind,
+		"zx":   parser.UnknownKind,
+	} {
+		if got := r.Kind(name); got != exp {
+			t.Errorf("%s: exp=%v got=%v", name, exp, got)
+		}
+	}
+	if def, _ := r.Lookup("zwj"); !def.Closed || def.DefaultAttribute != "gloss" {
+		t.Errorf("unexpected zwj definition: %+v", def)
+	}
+	if parser.DefaultRegistry().Kind("zq1") != parser.UnknownKind {
+		t.Errorf("expected the default registry to be unchanged")
+	}
+}
+
+// Ensure custom markers parse according to the stylesheet.
+func TestParser_Stylesheet(t *testing.T) {
+	sty, err := parser.ParseStylesheet(strings.NewReader(customSty))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := `\c 1
+\zq1 \v 1 Poetry \zwj red|grace\zwj* text \zm-s |Me\*x\zm-e\*`
+	content, err := parser.NewParserWithOptions(strings.NewReader(s), parser.Options{Stylesheet: sty}).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	exp := `c(1) zq1(v(1 Poetry zwj(red |grace) text zm-s(|Me) x zm-e()))`
+	if got := outline(content.Children); got != exp {
+		t.Errorf("content mismatch:\n\nexp=%s\n\ngot=%s\n\n", exp, got)
+	}
+	var gloss string
+	parser.Inspect(content, func(c *parser.Cursor) bool {
+		if c.Node.IsMarker("zwj") {
+			gloss = c.Node.Attributes["gloss"]
+		}
+		return true
+	})
+	if gloss != "grace" {
+		t.Errorf("expected the default attribute gloss=grace, got %q", gloss)
+	}
+}
+
+// Ensure the node helpers classify markers with the stylesheet of the parse.
+func TestParser_StylesheetHelpers(t *testing.T) {
+	sty, err := parser.ParseStylesheet(strings.NewReader(`\Marker zp
+\StyleType Paragraph
+\TextType VerseText
+
+\Marker zs
+\StyleType Paragraph
+\TextType Section
+
+\Marker zf
+\Endmarker zf*
+\StyleType Note
+\TextType NoteText`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := `\c 1 \zs T1 \r Joh 1:1 \zp \v 1 T2 \zf + T3\zf*`
+	content, err := parser.NewParserWithOptions(strings.NewReader(s), parser.Options{Stylesheet: sty}).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zs, zp := content.Children[1], content.Children[2]
+	if heading, ok := zs.Heading(); !ok || heading.Level != 1 || heading.Text != "T1" || !reflect.DeepEqual(heading.References, []string{"JHN.1.1"}) {
+		t.Errorf("unexpected heading: %+v %v", heading, ok)
+	}
+	if para, ok := zp.Paragraph(); !ok || !zp.IsParagraph() || para.Style != "zp" {
+		t.Errorf("unexpected paragraph: %+v %v", para, ok)
+	}
+	if zf := zp.Children[0].Children[2]; !zf.IsNote() {
+		t.Errorf("expected \\zf to be a note: %+v", zf)
+	}
+
+	// The same markers are unknown without the stylesheet
+	content, _ = parser.NewParser(strings.NewReader(s)).Parse()
+	for _, c := range content.Children {
+		if _, ok := c.Paragraph(); ok && c.Marker() != "p" {
+			t.Errorf("unexpected paragraph %s", c.Value)
+		} else if _, ok := c.Heading(); ok {
+			t.Errorf("unexpected heading %s", c.Value)
+		}
+	}
+}
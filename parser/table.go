@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TableCell describes a table cell marker such as \th1, \tcr2 or \tc1-2.
+type TableCell struct {
+	// Header is true for heading cells (\th, \thr and \thc)
+	Header bool
+
+	// Align is "left", "right" or "center"
+	Align string
+
+	// Column is the first column of the cell, starting at 1
+	Column int
+
+	// Span is the number of columns covered by the cell
+	Span int
+}
+
+// isTableCell reports whether the marker name (without level) is a table cell.
+func isTableCell(name string) bool {
+	switch name {
+	case "th", "thr", "thc", "tc", "tcr", "tcc":
+		return true
+	}
+	return false
+}
+
+// TableCell returns the cell described by a table cell marker. It returns
+// false if the node is not a table cell.
+func (c *Content) TableCell() (TableCell, bool) {
+	def, level := c.registry().Lookup(c.Marker())
+	if def == nil || !isTableCell(def.Name) {
+		return TableCell{}, false
+	}
+
+	cell := TableCell{Header: strings.HasPrefix(def.Name, "th"), Align: "left", Column: level, Span: 1}
+	if strings.HasSuffix(def.Name, "r") {
+		cell.Align = "right"
+	} else if strings.HasSuffix(def.Name, "c") && def.Name != "tc" {
+		cell.Align = "center"
+	}
+	if i := strings.LastIndex(c.Marker(), "-"); i > 0 {
+		end, _ := strconv.Atoi(c.Marker()[i+1:])
+		cell.Span = end - level + 1
+	}
+	return cell, true
+}
+
+// parseTable reads consecutive \tr rows into a table node.
+func (p *Parser) parseTable(table *Content) error {
+	for {
+		_, lit, span := p.scanIgnoreWhitespace()
+		if name, end := splitMarker(lit); end || !strings.HasPrefix(lit, `\`) || !strings.EqualFold(name, "tr") {
+			p.unscan()
+			return nil
+		}
+		row := newMarker(lit, span)
+		table.Children = append(table.Children, row)
+		if err := p.parseRow(row); err != nil {
+			return err
+		}
+	}
+}
+
+// parseRow reads the cells of a table row.
+func (p *Parser) parseRow(row *Content) error {
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		if p.endsRow(tok, lit) {
+			p.unscan()
+			return nil
+		} else if p.isTableCell(lit) {
+			cell := newMarker(lit, span)
+			row.Children = append(row.Children, cell)
+			if err := p.parseCell(cell); err != nil {
+				return err
+			}
+		} else {
+			// Content before the first cell belongs to the row
+			p.unscan()
+			if err := p.parseCell(row); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseCell reads the content of a table cell until the next cell or row.
+// Verses in a cell hold the content up to the end of the cell.
+func (p *Parser) parseCell(cell *Content) error {
+	parent := cell
+	for {
+		tok, lit, span := p.scanIgnoreWhitespace()
+		kind := p.kind(tok, lit)
+		if p.endsRow(tok, lit) || p.isTableCell(lit) {
+			p.unscan()
+			return nil
+		} else if tok == MarkerV {
+			markerV := newMarker(lit, span)
+			if ok, err := p.parseVerseNumber(markerV); err != nil {
+				return err
+			} else if !ok {
+				return nil
+			}
+			cell.Children = append(cell.Children, markerV)
+			parent = markerV
+		} else if kind == NoteKind {
+			child := newMarker(lit, span)
+			parent.Children = append(parent.Children, child)
+			p.parseNote(child)
+			p.addReferences(child)
+		} else if kind == CharacterKind {
+			p.parseCharacter(parent, lit, span)
+		} else if kind == MilestoneKind {
+			child := newMarker(lit, span)
+			parent.Children = append(parent.Children, child)
+			p.parseMilestone(child)
+		} else if isUnknownMarker(tok, lit) {
+			p.parseUnknown(parent, lit, span)
+		} else {
+			child := newNode(TextNode, lit, span)
+			parent.Children = append(parent.Children, child)
+		}
+	}
+}
+
+// endsRow reports whether the token ends a table row. Any paragraph marker
+// (including the next \tr) ends the row.
+func (p *Parser) endsRow(tok Token, lit string) bool {
+	switch p.kind(tok, lit) {
+	case HeaderKind, ChapterKind, ParagraphKind:
+		return true
+	}
+	return tok == EOF
+}
+
+// isTableCell reports whether the literal is a table cell marker.
+func (p *Parser) isTableCell(lit string) bool {
+	name, end := splitMarker(lit)
+	if end || !strings.HasPrefix(lit, `\`) {
+		return false
+	}
+	def, _ := p.markers.Lookup(name)
+	return def != nil && isTableCell(def.Name)
+}
@@ -0,0 +1,91 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure table rows are grouped into a table of cells.
+func TestParserTable(t *testing.T) {
+	s := `\c 1 \tr \th1 Tribe \thr2 Number \tr \tc1 \v 20 Reuben \tcr2 46,500 \tr \tc1-2 Total \p \v 21 T1`
+	content, err := parser.NewParser(strings.NewReader(s)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	clearPositions(content)
+
+	table := content.Children[1]
+	exp := &parser.Content{
+		Type: "table",
+		Children: []*parser.Content{
+			&parser.Content{
+				Type:  "marker",
+				Value: `\tr`,
+				Children: []*parser.Content{
+					&parser.Content{Type: "marker", Value: `\th1`, Children: []*parser.Content{&parser.Content{Type: "text", Value: "Tribe"}}},
+					&parser.Content{Type: "marker", Value: `\thr2`, Children: []*parser.Content{&parser.Content{Type: "text", Value: "Number"}}},
+				},
+			},
+			&parser.Content{
+				Type:  "marker",
+				Value: `\tr`,
+				Children: []*parser.Content{
+					&parser.Content{
+						Type:  "marker",
+						Value: `\tc1`,
+						Children: []*parser.Content{
+							&parser.Content{
+								Type:  "marker",
+								Value: `\v`,
+								Children: []*parser.Content{
+									&parser.Content{Type: "versenumber", Value: "20"},
+									&parser.Content{Type: "text", Value: "Reuben"},
+								},
+							},
+						},
+					},
+					&parser.Content{Type: "marker", Value: `\tcr2`, Children: []*parser.Content{&parser.Content{Type: "text", Value: "46,500"}}},
+				},
+			},
+			&parser.Content{
+				Type:  "marker",
+				Value: `\tr`,
+				Children: []*parser.Content{
+					&parser.Content{Type: "marker", Value: `\tc1-2`, Children: []*parser.Content{&parser.Content{Type: "text", Value: "Total"}}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, table) {
+		t.Errorf("table mismatch:\n\nexp=%#v\n\ngot=%#v\n\n", exp, table)
+	}
+	if len(content.Children) != 3 || content.Children[2].VerseNumber() != "" || content.Children[2].Children[0].VerseNumber() != "21" {
+		t.Errorf("expected the paragraph after the table")
+	}
+}
+
+// Ensure table cell markers describe their column, span and alignment.
+func TestTableCell(t *testing.T) {
+	var tests = []struct {
+		marker string
+		cell   parser.TableCell
+		ok     bool
+	}{
+		{marker: `\th1`, cell: parser.TableCell{Header: true, Align: "left", Column: 1, Span: 1}, ok: true},
+		{marker: `\thr2`, cell: parser.TableCell{Header: true, Align: "right", Column: 2, Span: 1}, ok: true},
+		{marker: `\tcc3`, cell: parser.TableCell{Align: "center", Column: 3, Span: 1}, ok: true},
+		{marker: `\tc2-4`, cell: parser.TableCell{Align: "left", Column: 2, Span: 3}, ok: true},
+		{marker: `\tr`},
+		{marker: `\p`},
+	}
+
+	for i, tt := range tests {
+		cell, ok := (&parser.Content{Type: "marker", Value: tt.marker}).TableCell()
+		if ok != tt.ok || !reflect.DeepEqual(tt.cell, cell) {
+			t.Errorf("%d. %s: cell mismatch: exp=%#v got=%#v", i, tt.marker, tt.cell, cell)
+		}
+	}
+}
@@ -58,6 +58,12 @@ const (
 	// MarkerQ2 represents '\q2' marker for a poetry line
 	MarkerQ2
 
+	// MarkerQ3 represents '\q3' marker for a poetry line
+	MarkerQ3
+
+	// MarkerQ4 represents '\q4' marker for a poetry line
+	MarkerQ4
+
 	// MarkerQS represents '\qs' marker for the word 'selah'
 	MarkerQS
 
@@ -106,6 +112,21 @@ const (
 	// EndMarkerAdd represents '\add*' marker for words added by the translator for clarity
 	EndMarkerAdd
 
+	// MarkerFig represents '\fig' marker for illustrations
+	MarkerFig
+
+	// EndMarkerFig represents '\fig*' marker for illustrations
+	EndMarkerFig
+
+	// Marker represents a registered marker which has no dedicated token
+	Marker
+
+	// EndMarker represents the closing '*' form of a registered marker which has no dedicated token
+	EndMarker
+
+	// EndMilestone represents the '\*' closing a milestone marker (\qt-s |who="Jesus"\*)
+	EndMilestone
+
 	// Citation represents the citation/dict/thesaurus definitions in the \w marker
 	Citation
 
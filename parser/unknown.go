@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// isUnknownMarker reports whether the token is a marker missing from the
+// registry (e.g. a project specific \zx), or a known marker closed although
+// it can't be (e.g. \p*).
+func isUnknownMarker(tok Token, lit string) bool {
+	return tok == Illegal && strings.HasPrefix(lit, `\`)
+}
+
+// parseUnknown keeps an unknown marker as an "unknown-marker" node with its
+// raw text and reports it. Its scope is guessed from the closing marker: the
+// content up to a matching \zx* (or \* for a milestone) is read into the
+// node, otherwise the marker stands alone and the content stays in parent.
+func (p *Parser) parseUnknown(parent *Content, lit string, span Span) {
+	p.warn(Diagnostic{Code: CodeUnknownMarker, Message: fmt.Sprintf("unknown marker %s", lit), Span: span, Found: Illegal, Literal: lit})
+	node := newNode(UnknownMarkerNode, lit, span)
+	parent.Children = append(parent.Children, node)
+	if _, end := splitMarker(lit); end {
+		return
+	}
+
+	if !p.parseSpan(node) {
+		parent.Children = append(parent.Children, node.Children...)
+		node.Children = nil
+		node.Attributes = nil
+		node.Span.End = span.End
+	}
+}
@@ -0,0 +1,71 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure unknown markers are kept with the content up to their closing
+// marker and reported.
+func TestParserUnknownMarkers(t *testing.T) {
+	var tests = []struct {
+		s     string
+		verse string
+		diags []string
+	}{
+		{s: `T1 \zx T2\zx* T3`, verse: `T1 unknown-marker(T2) T3`, diags: []string{`unknown marker \zx`}},
+		{s: `T1 \zx T2 T3`, verse: `T1 \zx T2 T3`, diags: []string{`unknown marker \zx`}},
+		{s: `\wj T1 \zx T2\zx*\wj* T3`, verse: `wj(T1 unknown-marker(T2)) T3`, diags: []string{`unknown marker \zx`}},
+		{s: `\zx T1 \wj T2 \zx* T3`, verse: `unknown-marker(T1 wj(T2)) T3`, diags: []string{`unknown marker \zx`, `\wj is not closed, expected \wj*`}},
+		{s: `T1 \zm-s |who="Me"\*T2\zm-e\*`, verse: `T1 unknown-marker(|who="Me") T2 \zm-e`, diags: []string{`unknown marker \zm-s`, `unknown marker \zm-e`}},
+		{s: `T1 \zx* T2 \p* T3`, verse: `T1 \zx* T2 \p* T3`, diags: []string{`unknown marker \zx*`, `unknown marker \p*`}},
+		{s: `\f + \ft T1 \zx T2\zx*\f* T3`, verse: `f(+ ft(T1 unknown-marker(T2))) T3`, diags: []string{`unknown marker \zx`}},
+	}
+
+	for i, tt := range tests {
+		p := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 ` + tt.s))
+		content, err := p.Parse()
+		if err != nil {
+			t.Errorf("%d. %q: unexpected error: %v", i, tt.s, err)
+			continue
+		}
+
+		v := content.Children[2].Children[0]
+		if got := outline(v.Children[1:]); got != tt.verse {
+			t.Errorf("%d. %q: verse mismatch:\n\nexp=%s\n\ngot=%s\n\n", i, tt.s, tt.verse, got)
+		}
+		var diags []string
+		for _, d := range p.Diagnostics() {
+			diags = append(diags, d.Message)
+		}
+		if !reflect.DeepEqual(tt.diags, diags) {
+			t.Errorf("%d. %q: diagnostics mismatch: exp=%q got=%q", i, tt.s, tt.diags, diags)
+		}
+	}
+}
+
+// Ensure unknown markers outside paragraphs are kept.
+func TestParserUnknownMarkers_Book(t *testing.T) {
+	s := `\id MAT
+\zhdr T1
+\c 1
+\zp \v 1 T2`
+	p := parser.NewParser(strings.NewReader(s))
+	content, err := p.Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := `id(MAT) \zhdr T1 c(1) \zp p(v(1 T2))`
+	if got := outline(content.Children); got != exp {
+		t.Errorf("content mismatch:\n\nexp=%s\n\ngot=%s\n\n", exp, got)
+	}
+	if k := content.Children[1].Kind(); k != parser.UnknownMarkerNode {
+		t.Errorf("expected an unknown-marker node, got %q", k)
+	}
+	if d := p.Diagnostics(); len(d) != 2 || d[0].Code != parser.CodeUnknownMarker || d[0].Severity != parser.SeverityWarning || d[0].Literal != `\zhdr` {
+		t.Errorf("unexpected diagnostics: %+v", d)
+	}
+}
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// VerseRange is a parsed verse number such as "4", "4a" or "1-3".
+type VerseRange struct {
+	// Start is the first verse
+	Start int
+
+	// End is the last verse (equal to Start for a single verse)
+	End int
+
+	// StartSegment is the segment of the first verse (e.g. "a" in "4a")
+	StartSegment string
+
+	// EndSegment is the segment of the last verse (e.g. "b" in "1-3b")
+	EndSegment string
+}
+
+// verseNumber matches "4", "4a", "1-3" and "1a-3b" style verse numbers
+var verseNumber = regexp.MustCompile(`^(\d+)(\p{Ll}?)(?:[-–](\d+)(\p{Ll}?))?$`)
+
+// isVerseNumber reports whether the literal is a valid verse number.
+func isVerseNumber(lit string) bool {
+	_, err := ParseVerseRange(lit)
+	return err == nil
+}
+
+// ParseVerseRange parses a verse number such as "4", "4a" or "1-3".
+func ParseVerseRange(s string) (VerseRange, error) {
+	m := verseNumber.FindStringSubmatch(s)
+	if m == nil {
+		return VerseRange{}, fmt.Errorf("invalid verse number %q", s)
+	}
+	r := VerseRange{StartSegment: m[2], EndSegment: m[2]}
+	r.Start, _ = strconv.Atoi(m[1])
+	r.End = r.Start
+	if m[3] != "" {
+		r.End, _ = strconv.Atoi(m[3])
+		r.EndSegment = m[4]
+		if r.End < r.Start {
+			return VerseRange{}, fmt.Errorf("invalid verse range %q", s)
+		}
+	}
+	return r, nil
+}
+
+// IsRange reports whether the range covers more than one verse.
+func (r VerseRange) IsRange() bool {
+	return r.End != r.Start
+}
+
+// Verses returns every verse covered by the range.
+func (r VerseRange) Verses() []int {
+	var verses []int
+	for v := r.Start; v <= r.End; v++ {
+		verses = append(verses, v)
+	}
+	return verses
+}
+
+// String returns the verse number as written in USFM.
+func (r VerseRange) String() string {
+	s := strconv.Itoa(r.Start) + r.StartSegment
+	if r.IsRange() {
+		s += "-" + strconv.Itoa(r.End) + r.EndSegment
+	}
+	return s
+}
+
+// OSIS returns the OSIS ID of the range within a chapter ID such as
+// "MAT.1", e.g. "MAT.1.4" or "MAT.1.1-MAT.1.3".
+func (r VerseRange) OSIS(chapter string) string {
+	id := chapter + "." + strconv.Itoa(r.Start)
+	if r.IsRange() {
+		id += "-" + chapter + "." + strconv.Itoa(r.End)
+	}
+	return id
+}
@@ -0,0 +1,58 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// Ensure verse numbers are parsed into ranges.
+func TestParseVerseRange(t *testing.T) {
+	var tests = []struct {
+		s      string
+		r      parser.VerseRange
+		verses []int
+		osis   string
+		err    string
+	}{
+		{s: "4", r: parser.VerseRange{Start: 4, End: 4}, verses: []int{4}, osis: "MAT.1.4"},
+		{s: "4a", r: parser.VerseRange{Start: 4, End: 4, StartSegment: "a", EndSegment: "a"}, verses: []int{4}, osis: "MAT.1.4"},
+		{s: "1-3", r: parser.VerseRange{Start: 1, End: 3}, verses: []int{1, 2, 3}, osis: "MAT.1.1-MAT.1.3"},
+		{s: "1b-2a", r: parser.VerseRange{Start: 1, End: 2, StartSegment: "b", EndSegment: "a"}, verses: []int{1, 2}, osis: "MAT.1.1-MAT.1.2"},
+		{s: "3-1", err: `invalid verse range "3-1"`},
+		{s: "X", err: `invalid verse number "X"`},
+	}
+
+	for i, tt := range tests {
+		r, err := parser.ParseVerseRange(tt.s)
+		if !reflect.DeepEqual(tt.err, errstring(err)) {
+			t.Errorf("%d. %q: error mismatch:\n  exp=%s\n  got=%s\n\n", i, tt.s, tt.err, err)
+		} else if tt.err != "" {
+			continue
+		} else if !reflect.DeepEqual(tt.r, r) {
+			t.Errorf("%d. %q: range mismatch: exp=%#v got=%#v", i, tt.s, tt.r, r)
+		} else if !reflect.DeepEqual(tt.verses, r.Verses()) || r.OSIS("MAT.1") != tt.osis || r.String() != tt.s {
+			t.Errorf("%d. %q: mismatch: verses=%v osis=%q string=%q", i, tt.s, r.Verses(), r.OSIS("MAT.1"), r.String())
+		}
+	}
+}
+
+// Ensure alternate and published numbers are found on verses and chapters.
+func TestAltNumbers(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\c 2 \ca 3\ca* \cp B \p \v 1-2 \va 5\va* \vp 1b\vp* T1`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	markerC, markerV := content.Children[0], content.Children[1].Children[0]
+	if markerC.AltNumber() != "3" || markerC.PublishedNumber() != "B" {
+		t.Errorf("chapter numbers mismatch: alt=%q published=%q", markerC.AltNumber(), markerC.PublishedNumber())
+	}
+	if markerV.AltNumber() != "5" || markerV.PublishedNumber() != "1b" {
+		t.Errorf("verse numbers mismatch: alt=%q published=%q", markerV.AltNumber(), markerV.PublishedNumber())
+	}
+	if r, err := markerV.VerseRange(); err != nil || r.End != 2 {
+		t.Errorf("verse range mismatch: %#v %v", r, err)
+	}
+}
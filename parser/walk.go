@@ -0,0 +1,103 @@
+package parser
+
+// Cursor describes a node and its context during a walk. A Cursor is reused
+// by the walk, so it is only valid until the callback returns.
+type Cursor struct {
+	// Node is the current node
+	Node *Content
+
+	// Ancestors are the parents of the node, starting with the root
+	Ancestors []*Content
+
+	// Book is the book code of the walked book
+	Book string
+
+	// Chapter is the number of the current chapter (empty before the first \c)
+	Chapter string
+
+	// Verse is the number of the current verse (empty before the first \v of a chapter)
+	Verse string
+
+	milestones milestones
+}
+
+// Milestones returns the milestones started but not yet ended at the node,
+// e.g. the \qt-s quotation the node is part of.
+func (c *Cursor) Milestones() []*Milestone {
+	return c.milestones.open
+}
+
+// Parent returns the parent of the node, or nil for the root.
+func (c *Cursor) Parent() *Content {
+	if len(c.Ancestors) == 0 {
+		return nil
+	}
+	return c.Ancestors[len(c.Ancestors)-1]
+}
+
+// Reference returns the OSIS style reference of the node (e.g. "MAT.1.2").
+func (c *Cursor) Reference() string {
+	ref := c.Book
+	if c.Chapter != "" {
+		ref += "." + c.Chapter
+		if c.Verse != "" {
+			ref += "." + c.Verse
+		}
+	}
+	return ref
+}
+
+// Visitor is called for each node of a walk. Enter is called before the
+// children of a node and returns false to skip them. Leave is called after
+// the children, including when they were skipped.
+type Visitor interface {
+	Enter(c *Cursor) bool
+	Leave(c *Cursor)
+}
+
+// Walk traverses the tree below node in depth-first order, calling v for
+// each node. The book, chapter and verse of the cursor follow the \id, \c
+// and \v markers in document order.
+func Walk(node *Content, v Visitor) {
+	c := &Cursor{}
+	if node.Kind() == BookNode {
+		c.Book = node.Value
+	}
+	walk(node, v, c)
+}
+
+func walk(node *Content, v Visitor, c *Cursor) {
+	if node.IsMarker("id") && len(node.Children) > 0 {
+		c.Book = node.Children[0].Value
+	} else if node.IsMarker("c") {
+		c.Chapter = node.ChapterNumber()
+		c.Verse = ""
+	} else if node.IsMarker("v") {
+		c.Verse = node.VerseNumber()
+	} else if node.Kind() == MarkerNode {
+		c.milestones.add(node)
+	}
+
+	c.Node = node
+	if v.Enter(c) {
+		c.Ancestors = append(c.Ancestors, node)
+		for _, child := range node.Children {
+			walk(child, v, c)
+		}
+		c.Ancestors = c.Ancestors[:len(c.Ancestors)-1]
+	}
+	c.Node = node
+	v.Leave(c)
+}
+
+// inspector is the Visitor used by Inspect.
+type inspector func(c *Cursor) bool
+
+func (f inspector) Enter(c *Cursor) bool { return f(c) }
+func (f inspector) Leave(c *Cursor)      {}
+
+// Inspect traverses the tree below node in depth-first order, calling f for
+// each node. If f returns false the children of the node are skipped.
+func Inspect(node *Content, f func(c *Cursor) bool) {
+	Walk(node, inspector(f))
+}
@@ -0,0 +1,78 @@
+package parser_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/socceroos/usfm/parser"
+)
+
+// recorder records the order of Enter and Leave calls of a walk.
+type recorder struct {
+	events []string
+	skip   string
+}
+
+func (r *recorder) Enter(c *parser.Cursor) bool {
+	r.events = append(r.events, "enter "+c.Node.Value+" "+c.Reference())
+	return c.Node.Value != r.skip
+}
+
+func (r *recorder) Leave(c *parser.Cursor) {
+	r.events = append(r.events, "leave "+c.Node.Value)
+}
+
+// Ensure Walk visits nodes in order with their references.
+func TestWalk(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id MAT \c 1 \p \v 1 T1 \v 2 \wj T2\wj*`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := &recorder{skip: `\wj`}
+	parser.Walk(content, r)
+	exp := []string{
+		"enter MAT MAT",
+		`enter \id MAT`, "enter MAT MAT", "leave MAT", `leave \id`,
+		`enter \c MAT.1`, "enter 1 MAT.1", "leave 1", `leave \c`,
+		`enter \p MAT.1`,
+		`enter \v MAT.1.1`, "enter 1 MAT.1.1", "leave 1", "enter T1 MAT.1.1", "leave T1", `leave \v`,
+		`enter \v MAT.1.2`, "enter 2 MAT.1.2", "leave 2", `enter \wj MAT.1.2`, `leave \wj`, `leave \v`,
+		`leave \p`,
+		"leave MAT",
+	}
+	if !reflect.DeepEqual(exp, r.events) {
+		t.Errorf("walk mismatch:\n\nexp=%q\n\ngot=%q\n\n", exp, r.events)
+	}
+}
+
+// Ensure Inspect gives the ancestors of each node.
+func TestInspect(t *testing.T) {
+	content, err := parser.NewParser(strings.NewReader(`\id MAT \c 2 \p \v 3 \wj T1\wj*`)).Parse()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var found bool
+	parser.Inspect(content, func(c *parser.Cursor) bool {
+		if c.Node.Value != "T1" {
+			return true
+		}
+		found = true
+		var path []string
+		for _, a := range c.Ancestors {
+			path = append(path, a.Value)
+		}
+		if exp := []string{"MAT", `\p`, `\v`, `\wj`}; !reflect.DeepEqual(exp, path) {
+			t.Errorf("ancestors mismatch: exp=%q got=%q", exp, path)
+		}
+		if c.Parent().Value != `\wj` || c.Reference() != "MAT.2.3" {
+			t.Errorf("context mismatch: parent=%q ref=%q", c.Parent().Value, c.Reference())
+		}
+		return false
+	})
+	if !found {
+		t.Errorf("text node not visited")
+	}
+}
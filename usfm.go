@@ -6,20 +6,22 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/socceroos/usfm/json"
 )
 
 // Command Line Flags
 type flags struct {
-	Input     string
-	Output    string
-	Append    string
-	FmtSrc    string
-	FmtDest   string
-	KeyStart  int
-	ByteStart int64
-	Directory string
+	Input      string
+	Output     string
+	Append     string
+	FmtSrc     string
+	FmtDest    string
+	KeyStart   int
+	ByteStart  int64
+	Directory  string
+	Categories string
 }
 
 type IndexItem struct {
@@ -45,6 +47,18 @@ type IndexFormat struct {
         Index       map[int]IndexItem `json:"index"`
 }
 
+// splitCategories returns the categories of a comma separated list, without
+// the spaces around them and the empty ones.
+func splitCategories(s string) []string {
+	var categories []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
+
 func main() {
 	fl := new(flags)
 
@@ -57,10 +71,11 @@ func main() {
 	flag.IntVar(&fl.KeyStart, "key-start", 0, "Starting key (root bible map, 0 == beginning)")
 	flag.Int64Var(&fl.ByteStart, "byte-start", 0, "Offset the bytecount start (for calculation of future-conjoined USFM files)")
 	flag.StringVar(&fl.Directory, "d", "", "Generate outputs for all files in the target directory (handles key iteration based on basic sort of directory list).")
+	flag.StringVar(&fl.Categories, "categories", "", "Comma separated categories of the extended notes and sidebars to output (all if empty)")
 	flag.Parse()
 
 	// Options for JSON conversion
-	o := json.Options{}
+	o := json.Options{Categories: splitCategories(fl.Categories)}
 
 	var files []os.FileInfo
 	var dir string
@@ -1 +1,27 @@
-package main_test
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Ensure the -categories flag is split into trimmed, non-empty categories.
+func TestSplitCategories(t *testing.T) {
+	var tests = []struct {
+		s   string
+		exp []string
+	}{
+		{s: "", exp: nil},
+		{s: "People", exp: []string{"People"}},
+		{s: "People,Places", exp: []string{"People", "Places"}},
+		{s: " People , Places ", exp: []string{"People", "Places"}},
+		{s: "People,, ,Places,", exp: []string{"People", "Places"}},
+		{s: " , ", exp: nil},
+	}
+
+	for i, tt := range tests {
+		if got := splitCategories(tt.s); !reflect.DeepEqual(tt.exp, got) {
+			t.Errorf("%d. %q: categories mismatch: exp=%q got=%q", i, tt.s, tt.exp, got)
+		}
+	}
+}